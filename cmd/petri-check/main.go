@@ -0,0 +1,154 @@
+// Command petri-check compiles a workflow DSL file and verifies the resulting
+// Petri net: dead markings, unbounded places, dead and non-live transitions,
+// conservation of every declared resource, siphons that may empty out
+// (Commoner's condition) and workflow-net soundness.
+//
+//	go run ./cmd/petri-check [-max-states N] [-final place,...] [-invariants] [-dot graph.dot] workflows/pipeline_barrier.yml
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"petri-net-mvp/core/analysis"
+	"petri-net-mvp/core/workflow"
+	"petri-net-mvp/dsl"
+)
+
+func main() {
+	maxStates := flag.Int("max-states", analysis.DefaultMaxStates, "maximum number of states to explore")
+	final := flag.String("final", "", "comma-separated places that must all be marked for a dead marking to count as final")
+	dotFile := flag.String("dot", "", "write the reachability graph in DOT format to this file")
+	jsonFile := flag.String("json", "", "write the reachability graph as JSON to this file")
+	showInvariants := flag.Bool("invariants", false, "print the minimal P- and T-invariants")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] workflow.yml\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Soundness is reported below along with the other findings
+	parser := &dsl.Parser{SkipSoundness: true}
+	wf, err := parser.ParseFile(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("Error parsing workflow: %v\n", err)
+		os.Exit(2)
+	}
+	net, err := workflow.NewCompiler().Compile(wf)
+	if err != nil {
+		fmt.Printf("Error compiling workflow: %v\n", err)
+		os.Exit(2)
+	}
+
+	opts := analysis.CheckOptions{Options: analysis.Options{MaxStates: *maxStates}}
+	if *final != "" {
+		places := strings.Split(*final, ",")
+		opts.Final = func(marking map[string]int) bool {
+			for _, id := range places {
+				if marking[strings.TrimSpace(id)] == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	report, err := analysis.Check(net, opts)
+	if err != nil {
+		fmt.Printf("Error analyzing workflow: %v\n", err)
+		os.Exit(2)
+	}
+	report.Print(os.Stdout)
+
+	// Resources must be returned by every task that takes them
+	ok := report.OK()
+	for _, resource := range wf.Resources {
+		if err := analysis.CheckConservation(net, resource.ID); err != nil {
+			fmt.Printf("  ❌ resource %s: %v\n", resource.ID, err)
+			ok = false
+		} else {
+			fmt.Printf("  ✅ resource %s is conserved\n", resource.ID)
+		}
+	}
+
+	// Structural deadlock warnings, reported against workflow elements
+	warnings, err := workflow.CheckSiphons(wf)
+	if err != nil {
+		fmt.Printf("Error computing siphons: %v\n", err)
+		os.Exit(2)
+	}
+	for _, w := range warnings {
+		fmt.Printf("  ⚠️  siphon %v\n", w)
+	}
+
+	// Too many markings to decide soundness is not a finding
+	switch err := workflow.CheckSoundness(wf); {
+	case errors.Is(err, workflow.ErrSoundnessUndecided):
+		fmt.Printf("  ⚠️  %v\n", err)
+	case err != nil:
+		fmt.Printf("  ❌ %v\n", err)
+		ok = false
+	default:
+		fmt.Println("  ✅ workflow is sound")
+	}
+
+	if *showInvariants {
+		incidence, err := analysis.Incidence(net)
+		if err != nil {
+			fmt.Printf("Error building incidence matrix: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Println("P-invariants:")
+		for _, inv := range incidence.PInvariants() {
+			fmt.Printf("  %v\n", inv)
+		}
+		fmt.Println("T-invariants:")
+		for _, inv := range incidence.TInvariants() {
+			fmt.Printf("  %v\n", inv)
+		}
+	}
+
+	if *dotFile != "" || *jsonFile != "" {
+		graph, err := analysis.Reachability(net, opts.Options)
+		if err != nil {
+			fmt.Printf("Error building reachability graph: %v\n", err)
+			os.Exit(2)
+		}
+		if err := writeFile(*dotFile, graph.WriteDOT); err != nil {
+			fmt.Printf("Error writing %s: %v\n", *dotFile, err)
+			os.Exit(2)
+		}
+		if err := writeFile(*jsonFile, graph.WriteJSON); err != nil {
+			fmt.Printf("Error writing %s: %v\n", *jsonFile, err)
+			os.Exit(2)
+		}
+	}
+
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// writeFile creates path and fills it with write; an empty path is skipped.
+func writeFile(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
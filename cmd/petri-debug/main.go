@@ -0,0 +1,303 @@
+// Command petri-debug compiles a workflow DSL file and steps through the
+// resulting Petri net interactively: list enabled transitions, fire or step,
+// undo, set breakpoints on transitions or place conditions and inspect tokens.
+//
+//	go run ./cmd/petri-debug [-seed N] [-tokens place=N,...] workflows/api_rate_limit.yml
+//
+// Commands are read from standard input; type "help" for the list.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"petri-net-mvp/core/petrinet"
+	"petri-net-mvp/core/workflow"
+	"petri-net-mvp/dsl"
+)
+
+const help = `commands:
+  enabled (e)                 list transitions that can fire now
+  fire (f) <transition>       fire a transition, ignoring breakpoints
+  step (s) [n]                fire n transitions (default 1), stopping at breakpoints
+  continue (c)                step until a breakpoint or until nothing is enabled
+  undo (u) [n]                undo the last n firings (default 1)
+  history (h)                 list the firings that can be undone
+  break (b) <transition>      stop before the transition fires
+  break (b) <place> <op> <n>  stop once the condition becomes true, e.g. "b results > 5"
+  breakpoints (bl)            list breakpoints
+  delete (d) <id>             delete a breakpoint
+  marking (m)                 show token counts per place
+  tokens (t) <place>          show the tokens of a place and their data
+  add (a) <place> [n]         add n tokens (default 1) to a place; earlier firings
+                              can no longer be undone
+  quit (q)`
+
+// maxContinue bounds "continue" on nets that never become quiescent.
+const maxContinue = 1000
+
+func main() {
+	seed := flag.Int64("seed", 0, "seed for the net's conflict policy")
+	tokens := flag.String("tokens", "", "comma-separated place=N initial tokens, e.g. documents=3")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] workflow.yml\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Unsound workflows are what the debugger is for
+	parser := &dsl.Parser{SkipSoundness: true}
+	wf, err := parser.ParseFile(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("Error parsing workflow: %v\n", err)
+		os.Exit(2)
+	}
+	net, err := workflow.NewCompiler().Compile(wf)
+	if err != nil {
+		fmt.Printf("Error compiling workflow: %v\n", err)
+		os.Exit(2)
+	}
+	if *tokens != "" {
+		for _, spec := range strings.Split(*tokens, ",") {
+			place, count, _ := strings.Cut(spec, "=")
+			n, err := strconv.Atoi(count)
+			if err != nil {
+				fmt.Printf("Error in -tokens %q: %v\n", spec, err)
+				os.Exit(2)
+			}
+			if err := addTokens(net, strings.TrimSpace(place), n); err != nil {
+				fmt.Printf("Error in -tokens: %v\n", err)
+				os.Exit(2)
+			}
+		}
+	}
+
+	d := petrinet.NewDebugger(net, *seed)
+	ctx := context.Background()
+	fmt.Printf("Debugging %s (%d places, %d transitions). Type \"help\" for commands.\n",
+		net.Name, len(net.Places), len(net.Transitions))
+
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("(petri) ")
+		if !in.Scan() {
+			fmt.Println()
+			return
+		}
+		args := strings.Fields(in.Text())
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "quit" || args[0] == "q" {
+			return
+		}
+		if err := run(ctx, d, net, args); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	}
+}
+
+// run executes one debugger command.
+func run(ctx context.Context, d *petrinet.Debugger, net *petrinet.PetriNet, args []string) error {
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "help", "?":
+		fmt.Println(help)
+
+	case "enabled", "e":
+		enabled := d.Enabled()
+		if len(enabled) == 0 {
+			fmt.Println("no transition is enabled")
+		}
+		for _, t := range enabled {
+			fmt.Printf("  %s\n", t.ID)
+		}
+
+	case "fire", "f":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: fire <transition>")
+		}
+		ev, err := d.Fire(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		printFiring(ev)
+
+	case "step", "s", "continue", "c":
+		n := 1
+		if cmd == "continue" || cmd == "c" {
+			n = maxContinue
+		} else if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("usage: step [n]")
+			}
+		}
+		fired, bp, err := d.Step(ctx, n)
+		for _, ev := range fired {
+			printFiring(ev)
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case bp != nil:
+			fmt.Printf("breakpoint %v\n", bp)
+		case len(fired) < n:
+			fmt.Println("no transition is enabled")
+		}
+
+	case "undo", "u":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("usage: undo [n]")
+			}
+		}
+		for i := 0; i < n; i++ {
+			ev, err := d.Undo()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("  undid %s\n", ev.Transition.ID)
+		}
+
+	case "history", "h":
+		for i, ev := range d.History() {
+			fmt.Printf("  %d: %s\n", i+1, ev.Transition.ID)
+		}
+
+	case "break", "b":
+		var bp *petrinet.Breakpoint
+		var err error
+		switch len(args) {
+		case 0:
+			return fmt.Errorf("usage: break <transition> | break <place> <op> <n>")
+		case 1:
+			if _, ok := net.Transitions[args[0]]; ok {
+				bp, err = d.BreakOnTransition(args[0])
+				break
+			}
+			fallthrough
+		default:
+			var c petrinet.Condition
+			if c, err = petrinet.ParseCondition(strings.Join(args, " ")); err == nil {
+				bp, err = d.BreakWhen(c)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("breakpoint %v\n", bp)
+
+	case "breakpoints", "bl":
+		for _, bp := range d.Breakpoints() {
+			fmt.Printf("  %v\n", bp)
+		}
+
+	case "delete", "d":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: delete <id>")
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil {
+			return fmt.Errorf("usage: delete <id>")
+		}
+		return d.DeleteBreakpoint(id)
+
+	case "marking", "m":
+		counts := net.Snapshot().Counts()
+		ids := make([]string, 0, len(counts))
+		for id := range counts {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Printf("  [%s]: %d tokens\n", id, counts[id])
+		}
+
+	case "tokens", "t":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: tokens <place>")
+		}
+		tokens, err := d.Tokens(args[0])
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			fmt.Println("  (empty)")
+		}
+		for _, tok := range tokens {
+			fmt.Printf("  %s: %v\n", tok.ID, tok.Data)
+		}
+
+	case "add", "a":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("usage: add <place> [n]")
+		}
+		n := 1
+		if len(args) == 2 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return fmt.Errorf("usage: add <place> [n]")
+			}
+		}
+		return addTokens(net, args[0], n)
+
+	default:
+		return fmt.Errorf("unknown command %q (type \"help\")", cmd)
+	}
+	return nil
+}
+
+// addTokens adds n tokens named after the place and their position.
+func addTokens(net *petrinet.PetriNet, placeID string, n int) error {
+	place, ok := net.Places[placeID]
+	if !ok {
+		return fmt.Errorf("unknown place %s", placeID)
+	}
+	start := place.TokenCount()
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("%s-%d", placeID, start+i)
+		if err := place.AddTokens(&petrinet.Token{ID: id, Data: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printFiring(ev petrinet.FiringEvent) {
+	fmt.Printf("  fired %s: %s -> %s\n", ev.Transition.ID, tokenList(ev.Consumed), tokenList(ev.Produced))
+}
+
+// tokenList formats tokens per place as "place[id id] place[id]" in place order.
+func tokenList(perPlace map[string][]*petrinet.Token) string {
+	ids := make([]string, 0, len(perPlace))
+	for id := range perPlace {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names := make([]string, 0, len(perPlace[id]))
+		for _, tok := range perPlace[id] {
+			names = append(names, tok.ID)
+		}
+		parts = append(parts, id+"["+strings.Join(names, " ")+"]")
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,209 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+// FindingKind classifies a Finding.
+type FindingKind string
+
+const (
+	// DeadMarking is a reachable marking in which no transition is enabled and
+	// that CheckOptions.Final does not accept.
+	DeadMarking FindingKind = "dead-marking"
+	// UnboundedPlace is a place that can accumulate arbitrarily many tokens.
+	UnboundedPlace FindingKind = "unbounded-place"
+	// DeadTransition is a transition that can never fire.
+	DeadTransition FindingKind = "dead-transition"
+	// NonLiveTransition is a transition that can become permanently disabled.
+	NonLiveTransition FindingKind = "non-live-transition"
+)
+
+// Finding is a property violation with the firing sequence that exhibits it.
+type Finding struct {
+	Kind       FindingKind
+	Place      string         // Set for UnboundedPlace
+	Transition string         // Set for DeadTransition and NonLiveTransition
+	Marking    map[string]int // The dead marking, or the marking after which Transition never fires again
+	Witness    []string       // Firing sequence from the initial marking; empty for DeadTransition
+}
+
+func (f Finding) String() string {
+	witness := "ε"
+	if len(f.Witness) > 0 {
+		witness = strings.Join(f.Witness, " → ")
+	}
+	switch f.Kind {
+	case DeadMarking:
+		return fmt.Sprintf("dead marking %s reached by %s", formatCounts(f.Marking), witness)
+	case UnboundedPlace:
+		return fmt.Sprintf("place %s is unbounded, first pumped by %s", f.Place, witness)
+	case DeadTransition:
+		return fmt.Sprintf("transition %s can never fire", f.Transition)
+	default:
+		return fmt.Sprintf("transition %s never fires again after %s in %s", f.Transition, witness, formatCounts(f.Marking))
+	}
+}
+
+// CheckOptions configures Check.
+type CheckOptions struct {
+	Options
+
+	// Final accepts dead markings that are legitimate end states, with the same
+	// signature as RunOptions.IsFinal. When nil every dead marking is reported.
+	Final func(marking map[string]int) bool
+}
+
+// Report lists the findings of Check.
+type Report struct {
+	Net      string
+	States   int  // Reachable markings explored
+	Complete bool // Whether the whole state space was explored; liveness is only checked then
+	Findings []Finding
+}
+
+// OK reports whether no finding was made.
+func (r *Report) OK() bool {
+	return len(r.Findings) == 0
+}
+
+// Print writes the report to w.
+func (r *Report) Print(w io.Writer) {
+	scope := "complete"
+	if !r.Complete {
+		scope = "truncated, liveness not checked"
+	}
+	fmt.Fprintf(w, "Analysis of %s: %d states (%s)\n", r.Net, r.States, scope)
+	if r.OK() {
+		fmt.Fprintln(w, "  ✅ no findings")
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "  ❌ %s: %s\n", f.Kind, f)
+	}
+}
+
+// Check explores the state space of net and reports dead markings that are not
+// final, unbounded places (through a coverability tree), dead transitions and
+// non-live transitions.
+func Check(net *petrinet.PetriNet, opts CheckOptions) (*Report, error) {
+	m, err := newModel(net, opts.Initial)
+	if err != nil {
+		return nil, err
+	}
+	g := m.explore(opts.MaxStates)
+	report := &Report{Net: m.name, States: len(g.States), Complete: g.Complete}
+
+	// Dead markings
+	for _, s := range g.States {
+		if len(s.Edges) > 0 || m.anyEnabled(s.Marking) {
+			continue
+		}
+		counts := g.Counts(s.ID)
+		if opts.Final != nil && opts.Final(counts) {
+			continue
+		}
+		report.Findings = append(report.Findings, Finding{Kind: DeadMarking, Marking: counts, Witness: g.Path(s.ID)})
+	}
+
+	// Boundedness: a finite reachability graph is bounded by construction. A
+	// complete coverability tree also decides dead transitions when it is not.
+	unbounded, fireable, covered := m.coverability(opts.MaxStates)
+	if !g.Complete {
+		for _, u := range unbounded {
+			report.Findings = append(report.Findings, Finding{Kind: UnboundedPlace, Place: m.places[u.place], Witness: u.witness})
+		}
+	} else {
+		fireable = make(map[string]bool)
+		for _, s := range g.States {
+			for _, e := range s.Edges {
+				fireable[e.Transition] = true
+			}
+		}
+	}
+	if g.Complete || covered {
+		for _, t := range m.trans {
+			if !fireable[t.id] {
+				report.Findings = append(report.Findings, Finding{Kind: DeadTransition, Transition: t.id})
+			}
+		}
+	}
+
+	// Liveness: a transition is live if it occurs in every terminal component
+	if g.Complete {
+		report.Findings = append(report.Findings, g.nonLive(fireable, opts.Final)...)
+	}
+	return report, nil
+}
+
+// anyEnabled reports whether some transition is enabled in mk.
+func (m *model) anyEnabled(mk Marking) bool {
+	for _, t := range m.trans {
+		if m.enabled(mk, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonLive reports fireable transitions missing from a terminal strongly
+// connected component, with the shortest path into that component. Dead
+// markings accepted by final are completed runs and do not count.
+func (g *Graph) nonLive(fireable map[string]bool, final func(map[string]int) bool) []Finding {
+	components := g.components()
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+
+	var findings []Finding
+	reported := make(map[string]bool)
+	for _, component := range components {
+		in := make(map[int]bool, len(component))
+		for _, id := range component {
+			in[id] = true
+		}
+		terminal := true
+		occurs := make(map[string]bool)
+		for _, id := range component {
+			for _, e := range g.States[id].Edges {
+				if !in[e.To] {
+					terminal = false
+				}
+				occurs[e.Transition] = true
+			}
+		}
+		entry := component[0]
+		if !terminal || len(occurs) == 0 && final != nil && final(g.Counts(entry)) {
+			continue
+		}
+		for _, id := range g.Transitions {
+			if !fireable[id] || occurs[id] || reported[id] {
+				continue
+			}
+			reported[id] = true
+			findings = append(findings, Finding{
+				Kind:       NonLiveTransition,
+				Transition: id,
+				Marking:    g.Counts(entry),
+				Witness:    g.Path(entry),
+			})
+		}
+	}
+	return findings
+}
+
+// formatCounts renders a count per place ID in ID order.
+func formatCounts(counts map[string]int) string {
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprintf("%s:%d", id, counts[id]))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		net   func(t *testing.T) *petrinet.PetriNet
+		final func(map[string]int) bool
+		want  []string // "kind subject" per finding
+	}{
+		{"live loop", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"p:1", "q"}, "go: p -> q", "back: q -> p")
+		}, nil, nil},
+		{"chain ends in a dead marking", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"a:1", "b", "c"}, "t1: a -> b", "t2: b -> c")
+		}, nil, []string{"dead-marking {c:1}", "non-live-transition t1", "non-live-transition t2"}},
+		{"chain ends in a final marking", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"a:1", "b", "c"}, "t1: a -> b", "t2: b -> c")
+		}, func(m map[string]int) bool { return m["c"] == 1 }, nil},
+		{"dead transition", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"p:1", "q", "z"}, "go: p -> q", "back: q -> p", "never: z -> p")
+		}, nil, []string{"dead-transition never"}},
+		{"unbounded place", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"p:1", "x"}, "pump: p -> p x")
+		}, nil, []string{"unbounded-place x"}},
+		{"lock order deadlock", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"a:1", "b:1", "l1:1", "l2:1", "a1", "b1", "done"},
+				"a_first: a l1 -> a1", "a_second: a1 l2 -> done l1 l2",
+				"b_first: b l2 -> b1", "b_second: b1 l1 -> done l1 l2")
+		}, func(m map[string]int) bool { return m["done"] == 2 }, []string{"dead-marking {a1:1, b1:1}",
+			"non-live-transition a_first", "non-live-transition a_second", "non-live-transition b_first", "non-live-transition b_second"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Check(tt.net(t), CheckOptions{Options: Options{MaxStates: 200}, Final: tt.final})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range report.Findings {
+				subject := f.Place + f.Transition
+				if f.Kind == DeadMarking {
+					subject = formatCounts(f.Marking)
+				}
+				got = append(got, fmt.Sprintf("%s %s", f.Kind, subject))
+				if f.Kind != DeadTransition && f.Kind != UnboundedPlace && len(f.Witness) == 0 {
+					t.Errorf("%s has no witness", f)
+				}
+			}
+			sort.Strings(got)
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+			if report.OK() != (len(tt.want) == 0) {
+				t.Errorf("OK() = %v with %d findings", report.OK(), len(got))
+			}
+		})
+	}
+}
+
+func TestReportPrint(t *testing.T) {
+	report, err := Check(spec(t, []string{"a:1", "b"}, "t1: a -> b"), CheckOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	report.Print(&buf)
+	for _, want := range []string{"Analysis of test: 2 states (complete)", "❌ dead-marking: dead marking {b:1} reached by t1"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package analysis
+
+// coverNode is a node of the Karp-Miller coverability tree.
+type coverNode struct {
+	marking Marking
+	parent  *coverNode
+	via     string
+}
+
+// path returns the firing sequence from the root to n.
+func (n *coverNode) path() []string {
+	var path []string
+	for ; n.parent != nil; n = n.parent {
+		path = append(path, n.via)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// unboundedPlace is a place that can hold arbitrarily many tokens, with the
+// firing sequence after which it was first accelerated to ω.
+type unboundedPlace struct {
+	place   int
+	witness []string
+}
+
+// coverability builds the Karp-Miller coverability tree breadth-first and
+// returns the unbounded places, the transitions enabled in some node, and
+// whether the tree was built completely within maxNodes. Capacities and
+// inhibitor arcs break monotonicity, so a marking is only accelerated over an
+// ancestor that holds exactly as many tokens in every such place. For nets with
+// reset arcs the construction is an approximation.
+func (m *model) coverability(maxNodes int) ([]unboundedPlace, map[string]bool, bool) {
+	if maxNodes <= 0 {
+		maxNodes = DefaultMaxStates
+	}
+
+	strict := make([]bool, len(m.places))
+	for p, limit := range m.capacity {
+		strict[p] = limit >= 0
+	}
+	for _, t := range m.trans {
+		for _, a := range t.inhibit {
+			strict[a.place] = true
+		}
+	}
+
+	var unbounded []unboundedPlace
+	found := make(map[int]bool)
+	fireable := make(map[string]bool)
+	seen := map[string]bool{m.initial.key(): true}
+	queue := []*coverNode{{marking: m.initial}}
+	nodes := 1
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, t := range m.trans {
+			if !m.enabled(n.marking, t) {
+				continue
+			}
+			fireable[t.id] = true
+
+			child := &coverNode{marking: m.fire(n.marking, t), parent: n, via: t.id}
+			for a := n; a != nil; a = a.parent {
+				if !covers(child.marking, a.marking, strict) {
+					continue
+				}
+				for p := range child.marking {
+					if child.marking[p] != omega && child.marking[p] > a.marking[p] {
+						child.marking[p] = omega
+					}
+				}
+			}
+			for p, count := range child.marking {
+				if count == omega && n.marking[p] != omega && !found[p] {
+					found[p] = true
+					unbounded = append(unbounded, unboundedPlace{place: p, witness: child.path()})
+				}
+			}
+
+			key := child.marking.key()
+			if seen[key] {
+				continue
+			}
+			if nodes >= maxNodes {
+				return unbounded, fireable, false
+			}
+			seen[key] = true
+			nodes++
+			queue = append(queue, child)
+		}
+	}
+	return unbounded, fireable, true
+}
+
+// covers reports whether a is greater than or equal to b in every place and
+// equal to it in strict places.
+func covers(a, b Marking, strict []bool) bool {
+	for p := range a {
+		if strict[p] && a[p] != b[p] {
+			return false
+		}
+		if a[p] == omega {
+			continue
+		}
+		if b[p] == omega || a[p] < b[p] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteDOT writes the graph in Graphviz DOT format. The initial state is drawn
+// with a double border and states without successors are filled.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", g.Net)
+	b.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	for _, s := range g.States {
+		counts := g.Counts(s.ID)
+		ids := make([]string, 0, len(counts))
+		for id := range counts {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		lines := []string{fmt.Sprintf("s%d", s.ID)}
+		for _, id := range ids {
+			lines = append(lines, fmt.Sprintf("%s=%d", id, counts[id]))
+		}
+
+		attrs := ""
+		switch {
+		case s.ID == 0:
+			attrs = ", peripheries=2"
+		case len(s.Edges) == 0:
+			attrs = ", style=filled, fillcolor=\"#f4cccc\""
+		}
+		fmt.Fprintf(&b, "  s%d [label=%q%s];\n", s.ID, strings.Join(lines, "\n"), attrs)
+	}
+	for _, s := range g.States {
+		for _, e := range s.Edges {
+			fmt.Fprintf(&b, "  s%d -> s%d [label=%q];\n", s.ID, e.To, e.Transition)
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type jsonEdge struct {
+	Transition string `json:"transition"`
+	To         int    `json:"to"`
+}
+
+type jsonState struct {
+	ID      int            `json:"id"`
+	Marking map[string]int `json:"marking"`
+	Edges   []jsonEdge     `json:"edges"`
+}
+
+type jsonGraph struct {
+	Net         string      `json:"net"`
+	Places      []string    `json:"places"`
+	Transitions []string    `json:"transitions"`
+	Complete    bool        `json:"complete"`
+	States      []jsonState `json:"states"`
+}
+
+// WriteJSON writes the graph as JSON, with markings keyed by place ID.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	out := jsonGraph{
+		Net:         g.Net,
+		Places:      g.Places,
+		Transitions: g.Transitions,
+		Complete:    g.Complete,
+		States:      make([]jsonState, 0, len(g.States)),
+	}
+	for _, s := range g.States {
+		state := jsonState{ID: s.ID, Marking: g.Counts(s.ID), Edges: make([]jsonEdge, 0, len(s.Edges))}
+		for _, e := range s.Edges {
+			state.Edges = append(state.Edges, jsonEdge{Transition: e.Transition, To: e.To})
+		}
+		out.States = append(out.States, state)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
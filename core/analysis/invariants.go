@@ -0,0 +1,250 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+// IncidenceMatrix is the token change caused by each transition in each place:
+// C[p][t] = output weight - input weight. Read and inhibitor arcs move no
+// tokens and reset arcs are not linear, so neither contributes.
+type IncidenceMatrix struct {
+	Places      []string // Row labels, sorted
+	Transitions []string // Column labels, sorted
+	C           [][]int
+}
+
+// Incidence builds the incidence matrix of net from its input and output arcs.
+func Incidence(net *petrinet.PetriNet) (*IncidenceMatrix, error) {
+	m, err := newModel(net, nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.incidence(), nil
+}
+
+func (m *model) incidence() *IncidenceMatrix {
+	im := &IncidenceMatrix{Places: m.places, C: make([][]int, len(m.places))}
+	for _, t := range m.trans {
+		im.Transitions = append(im.Transitions, t.id)
+	}
+	for p := range m.places {
+		im.C[p] = make([]int, len(m.trans))
+		for j, t := range m.trans {
+			im.C[p][j] = t.post[p] - t.pre[p]
+		}
+	}
+	return im
+}
+
+// Invariant is a non-negative integer weighting with minimal support, keyed by
+// place ID (P-invariant) or transition ID (T-invariant).
+type Invariant map[string]int
+
+// String renders the invariant as a weighted sum in ID order, e.g. "api_tokens + 2·x".
+func (inv Invariant) String() string {
+	ids := make([]string, 0, len(inv))
+	for id := range inv {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	terms := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if inv[id] == 1 {
+			terms = append(terms, id)
+		} else {
+			terms = append(terms, fmt.Sprintf("%d·%s", inv[id], id))
+		}
+	}
+	return strings.Join(terms, " + ")
+}
+
+// PInvariants returns the minimal P-invariants: place weightings y ≥ 0 with
+// yᵀC = 0, whose weighted token sum is the same in every reachable marking.
+func (im *IncidenceMatrix) PInvariants() []Invariant {
+	return invariants(im.C, im.Places)
+}
+
+// TInvariants returns the minimal T-invariants: firing counts x ≥ 0 with
+// Cx = 0, which reproduce the marking they start from.
+func (im *IncidenceMatrix) TInvariants() []Invariant {
+	transposed := make([][]int, len(im.Transitions))
+	for j := range im.Transitions {
+		transposed[j] = make([]int, len(im.Places))
+		for p := range im.Places {
+			transposed[j][p] = im.C[p][j]
+		}
+	}
+	return invariants(transposed, im.Transitions)
+}
+
+// farkasRow is a row of the Farkas tableau: the remaining columns of the
+// matrix and the combination of original rows that produced them.
+type farkasRow struct {
+	rest   []int
+	weight []int
+}
+
+// invariants computes the minimal-support non-negative integer vectors y with
+// yᵀA = 0 using the Farkas algorithm; labels name the rows of A.
+func invariants(a [][]int, labels []string) []Invariant {
+	rows := make([]farkasRow, len(a))
+	for i := range a {
+		rows[i] = farkasRow{rest: append([]int(nil), a[i]...), weight: make([]int, len(a))}
+		rows[i].weight[i] = 1
+	}
+
+	columns := 0
+	if len(a) > 0 {
+		columns = len(a[0])
+	}
+	for j := 0; j < columns; j++ {
+		var next []farkasRow
+		var positive, negative []farkasRow
+		for _, r := range rows {
+			switch {
+			case r.rest[j] == 0:
+				next = append(next, r)
+			case r.rest[j] > 0:
+				positive = append(positive, r)
+			default:
+				negative = append(negative, r)
+			}
+		}
+		for _, pr := range positive {
+			for _, nr := range negative {
+				fp, fn := -nr.rest[j], pr.rest[j]
+				combined := farkasRow{rest: make([]int, columns), weight: make([]int, len(a))}
+				for k := range combined.rest {
+					combined.rest[k] = fp*pr.rest[k] + fn*nr.rest[k]
+				}
+				for k := range combined.weight {
+					combined.weight[k] = fp*pr.weight[k] + fn*nr.weight[k]
+				}
+				next = append(next, combined.normalized())
+			}
+		}
+		rows = minimalSupport(next)
+	}
+
+	result := make([]Invariant, 0, len(rows))
+	for _, r := range rows {
+		inv := make(Invariant)
+		for i, w := range r.weight {
+			if w != 0 {
+				inv[labels[i]] = w
+			}
+		}
+		result = append(result, inv)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].String() < result[j].String() })
+	return result
+}
+
+// normalized divides the row by the gcd of its entries.
+func (r farkasRow) normalized() farkasRow {
+	g := 0
+	for _, v := range r.weight {
+		g = gcd(g, v)
+	}
+	for _, v := range r.rest {
+		g = gcd(g, v)
+	}
+	if g > 1 {
+		for k := range r.weight {
+			r.weight[k] /= g
+		}
+		for k := range r.rest {
+			r.rest[k] /= g
+		}
+	}
+	return r
+}
+
+// minimalSupport drops rows whose support strictly contains, or duplicates,
+// the support of another row.
+func minimalSupport(rows []farkasRow) []farkasRow {
+	var kept []farkasRow
+	for i, r := range rows {
+		minimal := true
+		for k, other := range rows {
+			if i == k {
+				continue
+			}
+			sub, equal := supportSubset(other.weight, r.weight)
+			if sub && (!equal || k < i) {
+				minimal = false
+				break
+			}
+		}
+		if minimal {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// supportSubset reports whether the support of a is contained in that of b,
+// and whether both supports are equal.
+func supportSubset(a, b []int) (subset, equal bool) {
+	equal = true
+	for k := range a {
+		if a[k] != 0 && b[k] == 0 {
+			return false, false
+		}
+		if (a[k] == 0) != (b[k] == 0) {
+			equal = false
+		}
+	}
+	return true, equal
+}
+
+func gcd(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// CheckConservation verifies that the token count of each listed place never
+// changes, i.e. that the place alone is a P-invariant and no transition resets
+// it. This proves, for example, that a workflow returns every api_tokens token
+// it takes.
+func CheckConservation(net *petrinet.PetriNet, places ...string) error {
+	m, err := newModel(net, nil)
+	if err != nil {
+		return err
+	}
+	im := m.incidence()
+
+	var violations []string
+	for _, id := range places {
+		p, ok := m.index[id]
+		if !ok {
+			return fmt.Errorf("place %s not found", id)
+		}
+		for j, t := range m.trans {
+			if im.C[p][j] != 0 {
+				violations = append(violations, fmt.Sprintf("%s changes %s by %+d", t.id, id, im.C[p][j]))
+			}
+			for _, r := range t.reset {
+				if r == p {
+					violations = append(violations, fmt.Sprintf("%s resets %s", t.id, id))
+				}
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("tokens not conserved: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
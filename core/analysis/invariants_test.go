@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+// mutex is two processes taking turns through a lock.
+func mutex(t *testing.T) *petrinet.PetriNet {
+	return spec(t, []string{"idle_a:1", "idle_b:1", "busy_a", "busy_b", "lock:1"},
+		"enter_a: idle_a lock -> busy_a", "leave_a: busy_a -> idle_a lock",
+		"enter_b: idle_b lock -> busy_b", "leave_b: busy_b -> idle_b lock")
+}
+
+func TestInvariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		net   func(t *testing.T) *petrinet.PetriNet
+		wantP []string
+		wantT []string
+	}{
+		{"mutual exclusion", mutex,
+			[]string{"busy_a + busy_b + lock", "busy_a + idle_a", "busy_b + idle_b"},
+			[]string{"enter_a + leave_a", "enter_b + leave_b"}},
+		{"weighted split and join", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"a:1", "b"}, "split: a -> b b", "join: b b -> a")
+		}, []string{"2·a + b"}, []string{"join + split"}},
+		{"source", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"a", "b"}, "src: -> a", "move: a -> b")
+		}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := Incidence(tt.net(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, tr := im.PInvariants(), im.TInvariants()
+			if got := invariantStrings(p); got != strings.Join(tt.wantP, "; ") {
+				t.Errorf("P-invariants = %s, want %s", got, strings.Join(tt.wantP, "; "))
+			}
+			if got := invariantStrings(tr); got != strings.Join(tt.wantT, "; ") {
+				t.Errorf("T-invariants = %s, want %s", got, strings.Join(tt.wantT, "; "))
+			}
+			// yᵀC = 0 and Cx = 0
+			for _, inv := range p {
+				for j, id := range im.Transitions {
+					sum := 0
+					for i, place := range im.Places {
+						sum += inv[place] * im.C[i][j]
+					}
+					if sum != 0 {
+						t.Errorf("P-invariant %s changes by %d when %s fires", inv, sum, id)
+					}
+				}
+			}
+			for _, inv := range tr {
+				for i, place := range im.Places {
+					sum := 0
+					for j, id := range im.Transitions {
+						sum += im.C[i][j] * inv[id]
+					}
+					if sum != 0 {
+						t.Errorf("T-invariant %s changes %s by %d", inv, place, sum)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckConservation(t *testing.T) {
+	// Each firing takes an api token and returns it, like a workflow resource.
+	tests := []struct {
+		name    string
+		prepare func(work *petrinet.Transition, api *petrinet.Place)
+		wantErr string
+	}{
+		{"returned", func(*petrinet.Transition, *petrinet.Place) {}, ""},
+		{"kept", func(work *petrinet.Transition, api *petrinet.Place) {
+			work.OutputArcs = work.OutputArcs[:1]
+		}, "work changes api by -1"},
+		{"reset", func(work *petrinet.Transition, api *petrinet.Place) {
+			work.AddResetArc(api)
+		}, "work resets api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := spec(t, []string{"jobs:3", "api:2", "done"}, "work: jobs api -> done api")
+			tt.prepare(net.Transitions["work"], net.Places["api"])
+			err := CheckConservation(net, "api")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckConservation() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckConservation() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if err := CheckConservation(mutex(t), "nowhere"); err == nil {
+		t.Error("CheckConservation() accepted an unknown place")
+	}
+}
+
+func invariantStrings(invs []Invariant) string {
+	s := make([]string, len(invs))
+	for i, inv := range invs {
+		s[i] = inv.String()
+	}
+	sort.Strings(s)
+	return strings.Join(s, "; ")
+}
@@ -0,0 +1,199 @@
+// Package analysis verifies properties of a PetriNet by exploring its state
+// space. Tokens are treated as counts: data, guards and timing are ignored, so
+// every result is an over-approximation of what the engine can do at runtime.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+// Marking is a token count per place, indexed like Graph.Places.
+type Marking []int
+
+// omega stands for an unbounded number of tokens in coverability markings.
+const omega = -1
+
+func (m Marking) key() string {
+	var b strings.Builder
+	for i, n := range m {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprint(&b, n)
+	}
+	return b.String()
+}
+
+type arc struct {
+	place  int
+	weight int
+}
+
+type transition struct {
+	id      string
+	pre     []int // Tokens consumed per place
+	post    []int // Tokens produced per place
+	read    []int // Tokens required but not consumed per place
+	inhibit []arc
+	reset   []int
+}
+
+// model is a count-based view of a PetriNet with places and transitions
+// sorted by ID, so that results are reproducible.
+type model struct {
+	name     string
+	places   []string
+	index    map[string]int
+	capacity []int
+	initial  Marking
+	trans    []*transition
+}
+
+func newModel(net *petrinet.PetriNet, initial map[string]int) (*model, error) {
+	m := &model{name: net.Name, index: make(map[string]int, len(net.Places))}
+	for id := range net.Places {
+		m.places = append(m.places, id)
+	}
+	sort.Strings(m.places)
+	for i, id := range m.places {
+		place := net.Places[id]
+		m.index[id] = i
+		m.capacity = append(m.capacity, place.Capacity)
+		m.initial = append(m.initial, place.TokenCount())
+	}
+	for id, n := range initial {
+		i, ok := m.index[id]
+		if !ok {
+			return nil, fmt.Errorf("initial marking references unknown place %s", id)
+		}
+		m.initial[i] = n
+	}
+
+	lookup := func(t *petrinet.Transition, p *petrinet.Place) (int, error) {
+		i, ok := m.index[p.ID]
+		if !ok || net.Places[p.ID] != p {
+			return 0, fmt.Errorf("transition %s references place %s that is not part of the net", t.ID, p.ID)
+		}
+		return i, nil
+	}
+	sum := func(t *petrinet.Transition, arcs []*petrinet.Arc) ([]int, error) {
+		counts := make([]int, len(m.places))
+		for _, a := range arcs {
+			p, err := lookup(t, a.Place)
+			if err != nil {
+				return nil, err
+			}
+			counts[p] += a.Weight
+		}
+		return counts, nil
+	}
+
+	ids := make([]string, 0, len(net.Transitions))
+	for id := range net.Transitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		t := net.Transitions[id]
+		tr := &transition{id: id}
+		var err error
+		if tr.pre, err = sum(t, t.InputArcs); err != nil {
+			return nil, err
+		}
+		if tr.post, err = sum(t, t.OutputArcs); err != nil {
+			return nil, err
+		}
+		if tr.read, err = sum(t, t.ReadArcs); err != nil {
+			return nil, err
+		}
+		for _, a := range t.InhibitorArcs {
+			p, err := lookup(t, a.Place)
+			if err != nil {
+				return nil, err
+			}
+			tr.inhibit = append(tr.inhibit, arc{place: p, weight: a.Weight})
+		}
+		for _, a := range t.ResetArcs {
+			p, err := lookup(t, a.Place)
+			if err != nil {
+				return nil, err
+			}
+			tr.reset = append(tr.reset, p)
+		}
+		m.trans = append(m.trans, tr)
+	}
+	return m, nil
+}
+
+// enabled reports whether t can fire in marking: enough tokens to consume and
+// read, every inhibitor place below its threshold, and room for the produced
+// tokens in bounded places. An ω place covers any demand but always inhibits.
+func (m *model) enabled(mk Marking, t *transition) bool {
+	for p := range mk {
+		if mk[p] != omega && mk[p] < t.pre[p]+t.read[p] {
+			return false
+		}
+	}
+	for _, a := range t.inhibit {
+		if mk[a.place] == omega || mk[a.place] >= a.weight {
+			return false
+		}
+	}
+	next := m.fire(mk, t)
+	for p, limit := range m.capacity {
+		if limit >= 0 && t.post[p] > 0 && next[p] != omega && next[p] > limit {
+			return false
+		}
+	}
+	return true
+}
+
+// fire returns the marking reached by firing t in mk.
+func (m *model) fire(mk Marking, t *transition) Marking {
+	next := make(Marking, len(mk))
+	copy(next, mk)
+	for p := range next {
+		if next[p] != omega {
+			next[p] -= t.pre[p]
+		}
+	}
+	for _, p := range t.reset {
+		next[p] = 0
+	}
+	for p := range next {
+		if next[p] != omega {
+			next[p] += t.post[p]
+		}
+	}
+	return next
+}
+
+// counts converts mk into a token count per place ID, omitting empty places.
+// Unbounded (ω) places are reported as -1.
+func (m *model) counts(mk Marking) map[string]int {
+	counts := make(map[string]int)
+	for p, n := range mk {
+		if n != 0 {
+			counts[m.places[p]] = n
+		}
+	}
+	return counts
+}
+
+// format renders the non-empty places of mk, e.g. "{api_tokens:3, documents:1}".
+func (m *model) format(mk Marking) string {
+	var parts []string
+	for p, n := range mk {
+		switch {
+		case n == omega:
+			parts = append(parts, m.places[p]+":ω")
+		case n != 0:
+			parts = append(parts, fmt.Sprintf("%s:%d", m.places[p], n))
+		}
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,240 @@
+package analysis
+
+import (
+	"petri-net-mvp/core/petrinet"
+)
+
+// DefaultMaxStates bounds the states explored by Reachability.
+const DefaultMaxStates = 10000
+
+// Options configures state-space exploration.
+type Options struct {
+	MaxStates int            // Maximum number of states to explore, 0 = DefaultMaxStates
+	Initial   map[string]int // Token counts overriding the current marking of the net, per place ID
+}
+
+// Edge is a firing from one state to another.
+type Edge struct {
+	Transition string
+	To         int
+}
+
+// State is a reachable marking.
+type State struct {
+	ID      int
+	Marking Marking
+	Edges   []Edge
+
+	parent int    // Predecessor on a shortest path from the initial state, -1 for the initial state
+	via    string // Transition fired from parent
+}
+
+// Graph is the reachability graph of a net. States[0] is the initial marking
+// and states are numbered in breadth-first order.
+type Graph struct {
+	Net         string
+	Places      []string // Place IDs, the index space of every Marking
+	Transitions []string // Transition IDs
+	States      []*State
+
+	// Complete is false when exploration stopped at MaxStates; states at the
+	// frontier then have no outgoing edges recorded.
+	Complete bool
+
+	model *model
+	index map[string]int
+}
+
+// Reachability enumerates the markings reachable from the current marking of
+// net (or opts.Initial) breadth-first.
+func Reachability(net *petrinet.PetriNet, opts Options) (*Graph, error) {
+	m, err := newModel(net, opts.Initial)
+	if err != nil {
+		return nil, err
+	}
+	return m.explore(opts.MaxStates), nil
+}
+
+func (m *model) explore(maxStates int) *Graph {
+	if maxStates <= 0 {
+		maxStates = DefaultMaxStates
+	}
+
+	g := &Graph{
+		Net:      m.name,
+		Places:   m.places,
+		Complete: true,
+		model:    m,
+		index:    make(map[string]int),
+	}
+	for _, t := range m.trans {
+		g.Transitions = append(g.Transitions, t.id)
+	}
+	g.add(m.initial, -1, "")
+
+	for i := 0; i < len(g.States); i++ {
+		s := g.States[i]
+		for _, t := range m.trans {
+			if !m.enabled(s.Marking, t) {
+				continue
+			}
+			next := m.fire(s.Marking, t)
+			to, seen := g.index[next.key()]
+			if !seen {
+				if len(g.States) >= maxStates {
+					g.Complete = false
+					continue
+				}
+				to = g.add(next, s.ID, t.id)
+			}
+			s.Edges = append(s.Edges, Edge{Transition: t.id, To: to})
+		}
+	}
+	return g
+}
+
+func (g *Graph) add(mk Marking, parent int, via string) int {
+	s := &State{ID: len(g.States), Marking: mk, parent: parent, via: via}
+	g.States = append(g.States, s)
+	g.index[mk.key()] = s.ID
+	return s.ID
+}
+
+// Counts returns the marking of state id as a token count per place ID,
+// omitting empty places.
+func (g *Graph) Counts(id int) map[string]int {
+	return g.model.counts(g.States[id].Marking)
+}
+
+// Format renders the marking of state id, e.g. "{api_tokens:3, documents:1}".
+func (g *Graph) Format(id int) string {
+	return g.model.format(g.States[id].Marking)
+}
+
+// Path returns a shortest firing sequence from the initial state to state id.
+func (g *Graph) Path(id int) []string {
+	var path []string
+	for s := g.States[id]; s.parent >= 0; s = g.States[s.parent] {
+		path = append(path, s.via)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Cycle returns a firing sequence that leads from some reachable state back to
+// itself, together with that state, or (nil, -1) if the explored graph is acyclic.
+func (g *Graph) Cycle() ([]string, int) {
+	for _, component := range g.components() {
+		in := make(map[int]bool, len(component))
+		for _, id := range component {
+			in[id] = true
+		}
+		start := component[0]
+		for _, e := range g.States[start].Edges {
+			if e.To == start {
+				return []string{e.Transition}, start
+			}
+		}
+		if len(component) == 1 {
+			continue
+		}
+		return g.loop(start, in), start
+	}
+	return nil, -1
+}
+
+// loop finds a shortest firing sequence from start back to start that stays
+// within a strongly connected component.
+func (g *Graph) loop(start int, in map[int]bool) []string {
+	type step struct {
+		prev int
+		via  string
+	}
+	visited := map[int]step{}
+	queue := []int{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, e := range g.States[id].Edges {
+			if !in[e.To] {
+				continue
+			}
+			if e.To == start {
+				path := []string{e.Transition}
+				for cur := id; cur != start; cur = visited[cur].prev {
+					path = append(path, visited[cur].via)
+				}
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			if _, seen := visited[e.To]; !seen {
+				visited[e.To] = step{prev: id, via: e.Transition}
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	return nil
+}
+
+// components returns the strongly connected components of the graph (Tarjan),
+// each listed with its lowest state ID first, in reverse topological order.
+func (g *Graph) components() [][]int {
+	index := make([]int, len(g.States))
+	low := make([]int, len(g.States))
+	onStack := make([]bool, len(g.States))
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	var result [][]int
+	next := 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, e := range g.States[v].Edges {
+			w := e.To
+			if index[w] < 0 {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var component []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		lowest := 0
+		for i, id := range component {
+			if id < component[lowest] {
+				lowest = i
+			}
+		}
+		component[0], component[lowest] = component[lowest], component[0]
+		result = append(result, component)
+	}
+
+	for v := range g.States {
+		if index[v] < 0 {
+			visit(v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,174 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+// spec describes a test net: "p:2" is a place with two tokens, "p:1/3" one
+// with one token and capacity 3, and "t: a b -> c" a transition taking a
+// token from a and b and putting one into c.
+func spec(t *testing.T, places []string, transitions ...string) *petrinet.PetriNet {
+	t.Helper()
+	net := petrinet.NewPetriNet("test")
+	for _, p := range places {
+		id, marking, _ := strings.Cut(p, ":")
+		tokens, capacity := 0, -1
+		if marking != "" {
+			count, limit, bounded := strings.Cut(marking, "/")
+			tokens = atoi(t, count)
+			if bounded {
+				capacity = atoi(t, limit)
+			}
+		}
+		place := petrinet.NewPlace(id, id, capacity)
+		for i := 0; i < tokens; i++ {
+			place.AddTokens(&petrinet.Token{ID: fmt.Sprintf("%s-%d", id, i)})
+		}
+		net.AddPlace(place)
+	}
+	for _, tr := range transitions {
+		id, arcs, _ := strings.Cut(tr, ":")
+		in, out, _ := strings.Cut(arcs, "->")
+		transition := petrinet.NewTransition(id, id)
+		for _, p := range strings.Fields(in) {
+			transition.AddInputArc(net.Places[p], 1)
+		}
+		for _, p := range strings.Fields(out) {
+			transition.AddOutputArc(net.Places[p], 1)
+		}
+		net.AddTransition(transition)
+	}
+	return net
+}
+
+func atoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestReachability(t *testing.T) {
+	tests := []struct {
+		name       string
+		net        func(t *testing.T) *petrinet.PetriNet
+		opts       Options
+		wantStates int
+		complete   bool
+		cyclic     bool
+	}{
+		{"chain", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"a:1", "b", "c"}, "t1: a -> b", "t2: b -> c")
+		}, Options{}, 3, true, false},
+		{"chain with an initial marking", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"a:1", "b", "c"}, "t1: a -> b", "t2: b -> c")
+		}, Options{Initial: map[string]int{"a": 2}}, 6, true, false},
+		{"loop", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"p:1", "q"}, "go: p -> q", "back: q -> p")
+		}, Options{}, 2, true, true},
+		{"bounded by capacity", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"x:0/2"}, "src: -> x")
+		}, Options{}, 3, true, false},
+		{"unbounded source", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"x"}, "src: -> x")
+		}, Options{MaxStates: 5}, 5, false, false},
+		{"mutual exclusion", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"idle_a:1", "idle_b:1", "busy_a", "busy_b", "lock:1"},
+				"enter_a: idle_a lock -> busy_a", "leave_a: busy_a -> idle_a lock",
+				"enter_b: idle_b lock -> busy_b", "leave_b: busy_b -> idle_b lock")
+		}, Options{}, 3, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := Reachability(tt.net(t), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(g.States) != tt.wantStates || g.Complete != tt.complete {
+				t.Errorf("%d states, complete %v, want %d, %v", len(g.States), g.Complete, tt.wantStates, tt.complete)
+			}
+			cycle, at := g.Cycle()
+			if (cycle != nil) != tt.cyclic {
+				t.Errorf("Cycle() = %v, want cyclic %v", cycle, tt.cyclic)
+			}
+			if cycle != nil && follow(g, at, cycle) != at {
+				t.Errorf("cycle %v does not lead from %s back to it", cycle, g.Format(at))
+			}
+			for _, s := range g.States {
+				if follow(g, 0, g.Path(s.ID)) != s.ID {
+					t.Errorf("path %v does not reach %s", g.Path(s.ID), g.Format(s.ID))
+				}
+			}
+		})
+	}
+}
+
+func TestReachabilityRejectsForeignPlaces(t *testing.T) {
+	net := spec(t, []string{"a:1"})
+	tr := petrinet.NewTransition("leak", "leak")
+	tr.AddInputArc(net.Places["a"], 1)
+	tr.AddOutputArc(petrinet.NewPlace("elsewhere", "elsewhere", -1), 1)
+	net.AddTransition(tr)
+	if _, err := Reachability(net, Options{}); err == nil {
+		t.Error("Reachability() accepted an arc to a place outside the net")
+	}
+	if _, err := Reachability(spec(t, []string{"a:1"}), Options{Initial: map[string]int{"b": 1}}); err == nil {
+		t.Error("Reachability() accepted an initial marking of an unknown place")
+	}
+}
+
+func TestGraphExport(t *testing.T) {
+	g, err := Reachability(spec(t, []string{"p:1", "q"}, "go: p -> q", "back: q -> p"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"digraph", `s0 -> s1 [label="go"]`, `s1 -> s0 [label="back"]`} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT lacks %q:\n%s", want, dot.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded jsonGraph
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.States) != 2 || decoded.States[1].Marking["q"] != 1 || !decoded.Complete {
+		t.Errorf("JSON graph = %+v, want two complete states, the second with q:1", decoded)
+	}
+}
+
+// follow fires path from state from and returns the state reached, or -1 if
+// a transition of path is not enabled on the way.
+func follow(g *Graph, from int, path []string) int {
+	cur := from
+	for _, tr := range path {
+		next := -1
+		for _, e := range g.States[cur].Edges {
+			if e.Transition == tr {
+				next = e.To
+			}
+		}
+		if next < 0 {
+			return -1
+		}
+		cur = next
+	}
+	return cur
+}
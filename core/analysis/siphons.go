@@ -0,0 +1,234 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+// Siphons and traps are computed on the input and output arcs of a net; a read
+// arc counts as both, since it needs a token and leaves it in place. Inhibitor
+// and reset arcs are ignored.
+
+// PlaceSet is a set of place IDs in ID order.
+type PlaceSet []string
+
+func (s PlaceSet) String() string {
+	return "{" + strings.Join(s, ", ") + "}"
+}
+
+// UnmarkedSiphon is a minimal siphon whose largest contained trap holds no
+// tokens initially, violating Commoner's condition: the siphon may be emptied,
+// and once empty it stays empty and every transition consuming from it is dead.
+type UnmarkedSiphon struct {
+	Siphon PlaceSet
+	Trap   PlaceSet // Largest trap inside the siphon, possibly empty
+}
+
+// Siphons returns the minimal siphons of net: place sets S whose every input
+// transition also consumes from S, so that an empty S never gains tokens.
+// The search explores at most opts.MaxStates candidate sets.
+func Siphons(net *petrinet.PetriNet, opts Options) ([]PlaceSet, error) {
+	m, err := newModel(net, opts.Initial)
+	if err != nil {
+		return nil, err
+	}
+	sets, err := m.minimalSets(false, opts.MaxStates)
+	return m.placeSets(sets), err
+}
+
+// Traps returns the minimal traps of net: place sets S whose every output
+// transition also produces into S, so that a marked S never becomes empty.
+func Traps(net *petrinet.PetriNet, opts Options) ([]PlaceSet, error) {
+	m, err := newModel(net, opts.Initial)
+	if err != nil {
+		return nil, err
+	}
+	sets, err := m.minimalSets(true, opts.MaxStates)
+	return m.placeSets(sets), err
+}
+
+// Commoner reports the minimal siphons that contain no initially marked trap.
+// It is a cheap structural deadlock warning that does not explore the state
+// space; for free-choice nets an empty result proves deadlock freedom.
+func Commoner(net *petrinet.PetriNet, opts Options) ([]UnmarkedSiphon, error) {
+	m, err := newModel(net, opts.Initial)
+	if err != nil {
+		return nil, err
+	}
+	siphons, err := m.minimalSets(false, opts.MaxStates)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []UnmarkedSiphon
+	for _, siphon := range siphons {
+		trap := m.maximalTrap(siphon)
+		marked := false
+		for p, in := range trap {
+			if in && m.initial[p] > 0 {
+				marked = true
+				break
+			}
+		}
+		if !marked {
+			result = append(result, UnmarkedSiphon{Siphon: m.placeSet(siphon), Trap: m.placeSet(trap)})
+		}
+	}
+	return result, nil
+}
+
+// consumes and produces report whether t takes tokens from or puts tokens into p.
+func (t *transition) consumes(p int) bool { return t.pre[p] > 0 || t.read[p] > 0 }
+func (t *transition) produces(p int) bool { return t.post[p] > 0 || t.read[p] > 0 }
+
+// minimalSets enumerates minimal siphons, or minimal traps when trap is set
+// (a trap is a siphon of the reversed net). Starting from each single place it
+// repeatedly picks a transition violating the condition and branches on the
+// places that would repair it.
+func (m *model) minimalSets(trap bool, limit int) ([][]bool, error) {
+	if limit <= 0 {
+		limit = DefaultMaxStates
+	}
+	into, from := (*transition).produces, (*transition).consumes
+	if trap {
+		into, from = from, into
+	}
+
+	var found [][]bool
+	visited := make(map[string]bool)
+	var search func(set []bool) error
+	search = func(set []bool) error {
+		key := setKey(set)
+		if visited[key] {
+			return nil
+		}
+		if len(visited) >= limit {
+			return fmt.Errorf("siphon/trap search stopped after %d candidate sets", limit)
+		}
+		visited[key] = true
+
+		for _, t := range m.trans {
+			fills, drains := false, false
+			for p, in := range set {
+				if in {
+					fills = fills || into(t, p)
+					drains = drains || from(t, p)
+				}
+			}
+			if !fills || drains {
+				continue
+			}
+			// t violates the condition: some place it draws from must join the set
+			for q := range m.places {
+				if !set[q] && from(t, q) {
+					next := append([]bool(nil), set...)
+					next[q] = true
+					if err := search(next); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+		found = append(found, set)
+		return nil
+	}
+
+	for p := range m.places {
+		set := make([]bool, len(m.places))
+		set[p] = true
+		if err := search(set); err != nil {
+			return nil, err
+		}
+	}
+
+	var minimal [][]bool
+	for i, set := range found {
+		keep := true
+		for k, other := range found {
+			if i != k && subset(other, set) && (!subset(set, other) || k < i) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			minimal = append(minimal, set)
+		}
+	}
+	sort.Slice(minimal, func(i, j int) bool { return m.placeSet(minimal[i]).String() < m.placeSet(minimal[j]).String() })
+	return minimal, nil
+}
+
+// maximalTrap returns the largest trap contained in set: places are removed
+// while some transition consuming from them produces nothing into the rest.
+func (m *model) maximalTrap(set []bool) []bool {
+	trap := append([]bool(nil), set...)
+	for changed := true; changed; {
+		changed = false
+		for p, in := range trap {
+			if !in {
+				continue
+			}
+			for _, t := range m.trans {
+				if !t.consumes(p) {
+					continue
+				}
+				refills := false
+				for q, kept := range trap {
+					if kept && t.produces(q) {
+						refills = true
+						break
+					}
+				}
+				if !refills {
+					trap[p] = false
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return trap
+}
+
+func (m *model) placeSet(set []bool) PlaceSet {
+	ids := PlaceSet{}
+	for p, in := range set {
+		if in {
+			ids = append(ids, m.places[p])
+		}
+	}
+	return ids
+}
+
+func (m *model) placeSets(sets [][]bool) []PlaceSet {
+	result := make([]PlaceSet, 0, len(sets))
+	for _, set := range sets {
+		result = append(result, m.placeSet(set))
+	}
+	return result
+}
+
+func setKey(set []bool) string {
+	b := make([]byte, len(set))
+	for i, in := range set {
+		b[i] = '0'
+		if in {
+			b[i] = '1'
+		}
+	}
+	return string(b)
+}
+
+// subset reports whether a is contained in b.
+func subset(a, b []bool) bool {
+	for i := range a {
+		if a[i] && !b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+func TestSiphonsAndTraps(t *testing.T) {
+	tests := []struct {
+		name         string
+		net          func(t *testing.T) *petrinet.PetriNet
+		wantSiphons  string
+		wantTraps    string
+		wantCommoner string // siphons without a marked trap, "siphon in trap"
+	}{
+		{"mutual exclusion", mutex,
+			"[{busy_a, busy_b, lock} {busy_a, idle_a} {busy_b, idle_b}]",
+			"[{busy_a, busy_b, lock} {busy_a, idle_a} {busy_b, idle_b}]",
+			"[]"},
+		{"join on a place nothing fills", func(t *testing.T) *petrinet.PetriNet {
+			return spec(t, []string{"a:1", "b", "c"}, "join: a b -> c")
+		}, "[{a} {b}]", "[{c}]", "[{a} in {} {b} in {}]"},
+		{"read arc", func(t *testing.T) *petrinet.PetriNet {
+			net := spec(t, []string{"jobs:1", "done", "config"}, "work: jobs -> done")
+			net.Transitions["work"].AddReadArc(net.Places["config"], 1)
+			return net
+		}, "[{config} {jobs}]", "[{config} {done}]", "[{config} in {config} {jobs} in {}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := tt.net(t)
+			siphons, err := Siphons(net, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			traps, err := Traps(net, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			unmarked, err := Commoner(net, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(siphons); got != tt.wantSiphons {
+				t.Errorf("Siphons() = %s, want %s", got, tt.wantSiphons)
+			}
+			if got := fmt.Sprint(traps); got != tt.wantTraps {
+				t.Errorf("Traps() = %s, want %s", got, tt.wantTraps)
+			}
+			var commoner []string
+			for _, u := range unmarked {
+				commoner = append(commoner, fmt.Sprintf("%s in %s", u.Siphon, u.Trap))
+			}
+			if got := fmt.Sprintf("%v", commoner); got != tt.wantCommoner {
+				t.Errorf("Commoner() = %s, want %s", got, tt.wantCommoner)
+			}
+		})
+	}
+}
+
+func TestSiphonSearchLimit(t *testing.T) {
+	if _, err := Siphons(mutex(t), Options{MaxStates: 2}); err == nil {
+		t.Error("Siphons() ignored the search limit")
+	}
+}
@@ -0,0 +1,212 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// arcNet builds a net whose transition move takes tokens from in to out and
+// has no other arcs yet; extra is a further place for the arc under test.
+func arcNet(extraTokens int) (net *PetriNet, tr *Transition, extra *Place) {
+	net = pipeNet("arcs", 3)
+	extra = NewPlace("extra", "extra", -1)
+	net.AddPlace(extra)
+	for i := 0; i < extraTokens; i++ {
+		extra.AddTokens(&Token{ID: fmt.Sprintf("x-%d", i)})
+	}
+	return net, net.Transitions["move"], extra
+}
+
+func TestInhibitorArc(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokens    int
+		threshold int
+		wantFire  bool
+	}{
+		{"empty place", 0, 1, true},
+		{"occupied place", 1, 1, false},
+		{"below threshold", 2, 3, true},
+		{"at threshold", 3, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, tr, extra := arcNet(tt.tokens)
+			tr.AddInhibitorArc(extra, tt.threshold)
+			if got := tr.CanFire(); got != tt.wantFire {
+				t.Errorf("CanFire() = %v, want %v", got, tt.wantFire)
+			}
+			err := tr.Fire(context.Background())
+			if tt.wantFire && err != nil {
+				t.Errorf("Fire() error = %v", err)
+			}
+			if !tt.wantFire && !errors.Is(err, ErrNotReady) {
+				t.Errorf("Fire() error = %v, want ErrNotReady", err)
+			}
+			if n := extra.TokenCount(); n != tt.tokens {
+				t.Errorf("inhibitor place holds %d tokens, want %d untouched", n, tt.tokens)
+			}
+		})
+	}
+}
+
+func TestInhibitorArcCountsTokensOfInflightFirings(t *testing.T) {
+	// handle takes the error token and fails, so the token comes back: move,
+	// inhibited by the error place, must not fire in the meantime.
+	net, move, errs := arcNet(1)
+	move.AddInhibitorArc(errs, 1)
+	release := make(chan struct{})
+	handle := NewTransition("handle", "handle")
+	handle.AddInputArc(errs, 1)
+	handle.Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+		<-release
+		return nil, errors.New("handler crashed")
+	}
+	net.AddTransition(handle)
+	r := &recorder{}
+	net.AddObserver(r)
+
+	done := make(chan *RunReport)
+	go func() {
+		report, _ := net.Run(context.Background(), RunOptions{MaxConcurrency: 2})
+		done <- report
+	}()
+	waitFor(t, func() bool { return r.count("started handle") == 1 })
+	if move.CanFire() {
+		t.Error("move can fire while the error token is only held by a firing")
+	}
+	close(release)
+	report := <-done
+
+	if report.StopReason != StopFailed {
+		t.Errorf("stop reason = %s, want %s", report.StopReason, StopFailed)
+	}
+	if got := report.FinalMarking; got["extra"] != 1 || got["out"] != 0 {
+		t.Errorf("final marking %v, want the error token back and nothing moved", got)
+	}
+}
+
+func TestReadArc(t *testing.T) {
+	_, tr, extra := arcNet(1)
+	tr.AddReadArc(extra, 1)
+	var input []string
+	tr.Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+		for _, tok := range tokens {
+			input = append(input, tok.ID)
+		}
+		return tokens[:1], nil
+	}
+	if err := tr.Fire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(input) != "[arcs-0 x-0]" {
+		t.Errorf("action input = %v, want the consumed token, then the read one", input)
+	}
+	if n := extra.TokenCount(); n != 1 {
+		t.Errorf("read place holds %d tokens, want 1", n)
+	}
+
+	extra.RemoveTokens(1)
+	if err := tr.Fire(context.Background()); !errors.Is(err, ErrNotReady) {
+		t.Errorf("Fire() without the read token error = %v, want ErrNotReady", err)
+	}
+}
+
+func TestReadArcConcurrentReadersBlockConsumers(t *testing.T) {
+	// Two firings read the context at once; a consumer of the context waits
+	// until both have finished.
+	net, reader, ctxPlace := arcNet(1)
+	reader.AddReadArc(ctxPlace, 1)
+	consumer := NewTransition("consume", "consume")
+	consumer.AddInputArc(ctxPlace, 1)
+	net.AddTransition(consumer)
+
+	var started sync.WaitGroup
+	started.Add(2)
+	release := make(chan struct{})
+	reader.Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+		started.Done()
+		<-release
+		return tokens[:1], nil
+	}
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- reader.Fire(context.Background()) }()
+	}
+	started.Wait()
+
+	if err := consumer.Fire(context.Background()); !errors.Is(err, ErrNotReady) {
+		t.Errorf("consuming during reads error = %v, want ErrNotReady", err)
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := consumer.Fire(context.Background()); err != nil {
+		t.Errorf("consuming after the reads error = %v", err)
+	}
+}
+
+func TestResetArc(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name      string
+		fail      bool
+		wantExtra int
+		wantReset int // tokens reported in FiringEvent.Reset
+	}{
+		{"empties the place", false, 0, 3},
+		{"failed action puts the tokens back", true, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net, tr, extra := arcNet(3)
+			tr.AddResetArc(extra)
+			if tt.fail {
+				tr.Action = func(context.Context, []*Token) ([]*Token, error) { return nil, failed }
+			}
+			r := &resetRecorder{}
+			net.AddObserver(r)
+			net.Run(context.Background(), RunOptions{MaxFirings: 1})
+
+			if n := extra.TokenCount(); n != tt.wantExtra {
+				t.Errorf("reset place holds %d tokens, want %d", n, tt.wantExtra)
+			}
+			if r.reset != tt.wantReset {
+				t.Errorf("event reports %d reset tokens, want %d", r.reset, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestResetArcOnInputPlace(t *testing.T) {
+	// The transition takes its own input first and drops the rest.
+	net, tr, _ := arcNet(0)
+	tr.AddResetArc(net.Places["in"])
+	if err := tr.Fire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n, m := net.Places["in"].TokenCount(), net.Places["out"].TokenCount(); n != 0 || m != 1 {
+		t.Errorf("in holds %d and out %d tokens, want 0 and 1", n, m)
+	}
+}
+
+// resetRecorder counts the tokens dropped by reset arcs in the firings it sees.
+type resetRecorder struct {
+	BaseObserver
+	reset int
+}
+
+func (r *resetRecorder) OnFiringCompleted(ev FiringEvent) { r.count(ev) }
+func (r *resetRecorder) OnFiringFailed(ev FiringEvent)    { r.count(ev) }
+
+func (r *resetRecorder) count(ev FiringEvent) {
+	for _, tokens := range ev.Reset {
+		r.reset += len(tokens)
+	}
+}
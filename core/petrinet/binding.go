@@ -0,0 +1,192 @@
+package petrinet
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultBindingLimit is the number of candidate bindings evaluated against a
+// guard per firing attempt when Transition.BindingLimit is not set.
+const DefaultBindingLimit = 1000
+
+// binding is a choice of tokens for the input and read arcs of a transition.
+type binding struct {
+	tokens     []*Token // consumed tokens in input-arc order, followed by read tokens
+	consumed   map[*Place][]*Token
+	read       map[*Place][]*Token
+	consumedAt map[*Place][]int // indices of the consumed tokens in Place.Tokens, ascending
+}
+
+// bind searches for tokens that satisfy the guard. Candidates are visited in
+// queue order, so without a guard (or when the head tokens match) the result
+// is the head of every place. Tokens rejected by the guard do not block tokens
+// behind them: the search tries other combinations across all input places
+// until one is accepted or BindingLimit candidates have been evaluated.
+// Only tokens available at now are considered; guard is normally t.Guard.
+// Callers must hold the locks of all input and read places.
+func (t *Transition) bind(now time.Time, guard func([]*Token) bool) (*binding, error) {
+	arcs := make([]*Arc, 0, len(t.InputArcs)+len(t.ReadArcs))
+	arcs = append(arcs, t.InputArcs...)
+	arcs = append(arcs, t.ReadArcs...)
+
+	if guard == nil {
+		picks, ok := bindFirst(now, arcs)
+		if !ok {
+			return nil, ErrNotReady
+		}
+		return t.newBinding(arcs, picks), nil
+	}
+
+	limit := t.BindingLimit
+	if limit <= 0 {
+		limit = DefaultBindingLimit
+	}
+
+	// picks holds, per arc, the indices of the chosen tokens in its place.
+	picks := make([][]int, len(arcs))
+	taken := func(place *Place, idx int) bool {
+		for a, arc := range arcs {
+			if arc.Place != place {
+				continue
+			}
+			for _, picked := range picks[a] {
+				if picked == idx {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	collect := func() []*Token {
+		var tokens []*Token
+		for i, arc := range arcs {
+			for _, idx := range picks[i] {
+				tokens = append(tokens, arc.Place.Tokens[idx])
+			}
+		}
+		return tokens
+	}
+
+	tried := 0
+	var first []*Token
+	var search func(a, start, left int) bool
+	search = func(a, start, left int) bool {
+		if a == len(arcs) {
+			tokens := collect()
+			if first == nil {
+				first = tokens
+			}
+			tried++
+			return guard(tokens)
+		}
+		if left == 0 {
+			next := 0
+			if a+1 < len(arcs) {
+				next = arcs[a+1].Weight
+			}
+			return search(a+1, 0, next)
+		}
+
+		place := arcs[a].Place
+		for i := start; i <= len(place.Tokens)-left; i++ {
+			if tried >= limit {
+				return false
+			}
+			if !place.Tokens[i].availableAt(now) || taken(place, i) {
+				continue
+			}
+			picks[a] = append(picks[a], i)
+			if search(a, i+1, left-1) {
+				return true
+			}
+			picks[a] = picks[a][:len(picks[a])-1]
+		}
+		return false
+	}
+
+	firstWeight := 0
+	if len(arcs) > 0 {
+		firstWeight = arcs[0].Weight
+	}
+	if !search(0, 0, firstWeight) {
+		if first == nil {
+			return nil, ErrNotReady // not enough tokens for any binding
+		}
+		return nil, &guardRejected{tokens: first}
+	}
+	return t.newBinding(arcs, picks), nil
+}
+
+// bindFirst picks for every arc in turn the first Weight available tokens not
+// taken by an earlier arc on the same place. That is the binding the search
+// visits first, so it is the result whenever there is no guard, found without
+// backtracking. It reports false when some place runs out of tokens.
+func bindFirst(now time.Time, arcs []*Arc) ([][]int, bool) {
+	next := make(map[*Place]int, len(arcs)) // first index not looked at yet
+	picks := make([][]int, len(arcs))
+	for a, arc := range arcs {
+		tokens := arc.Place.Tokens
+		i := next[arc.Place]
+		for ; i < len(tokens) && len(picks[a]) < arc.Weight; i++ {
+			if tokens[i].availableAt(now) {
+				picks[a] = append(picks[a], i)
+			}
+		}
+		if len(picks[a]) < arc.Weight {
+			return nil, false
+		}
+		next[arc.Place] = i
+	}
+	return picks, true
+}
+
+// newBinding collects the tokens picked for arcs, which are the input arcs of
+// t followed by its read arcs.
+func (t *Transition) newBinding(arcs []*Arc, picks [][]int) *binding {
+	b := &binding{
+		consumed:   make(map[*Place][]*Token),
+		read:       make(map[*Place][]*Token),
+		consumedAt: make(map[*Place][]int),
+	}
+	for i, arc := range arcs {
+		for _, idx := range picks[i] {
+			tok := arc.Place.Tokens[idx]
+			b.tokens = append(b.tokens, tok)
+			if i < len(t.InputArcs) {
+				b.consumed[arc.Place] = append(b.consumed[arc.Place], tok)
+				b.consumedAt[arc.Place] = append(b.consumedAt[arc.Place], idx)
+			} else {
+				b.read[arc.Place] = append(b.read[arc.Place], tok)
+			}
+		}
+	}
+	for _, indices := range b.consumedAt {
+		sort.Ints(indices)
+	}
+	return b
+}
+
+// removeIndices returns queue without the tokens at the given ascending
+// indices, preserving order. The tokens ahead of the last index move towards
+// the tail and the queue is resliced past the removed ones, so removing near
+// the head, the usual case, does not touch the rest of the queue.
+func removeIndices(queue []*Token, indices []int) []*Token {
+	if len(indices) == 0 {
+		return queue
+	}
+	last := indices[len(indices)-1]
+	w, k := last, len(indices)-1
+	for r := last; r >= 0; r-- {
+		if k >= 0 && indices[k] == r {
+			k--
+			continue
+		}
+		queue[w] = queue[r]
+		w--
+	}
+	for i := 0; i <= w; i++ {
+		queue[i] = nil
+	}
+	return queue[w+1:]
+}
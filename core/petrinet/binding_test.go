@@ -0,0 +1,184 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBindingSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		orders   []string // Data of the tokens in orders
+		invoices []string // Data of the tokens in invoices
+		limit    int
+		want     string // "order/invoice" consumed, "" if nothing fires
+	}{
+		{"heads match", []string{"a"}, []string{"a"}, 0, "a/a"},
+		{"rejected head does not block", []string{"a", "b"}, []string{"b"}, 0, "b/b"},
+		{"match behind both heads", []string{"a", "b", "c"}, []string{"d", "c"}, 0, "c/c"},
+		{"no match", []string{"a"}, []string{"b"}, 0, ""},
+		{"match beyond the binding limit", []string{"a", "b", "c"}, []string{"x", "y", "c"}, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := NewPetriNet("join")
+			orders := NewPlace("orders", "orders", -1)
+			invoices := NewPlace("invoices", "invoices", -1)
+			matched := NewPlace("matched", "matched", -1)
+			for _, p := range []*Place{orders, invoices, matched} {
+				net.AddPlace(p)
+			}
+			for i, key := range tt.orders {
+				orders.AddTokens(&Token{ID: fmt.Sprintf("o-%d", i), Data: key})
+			}
+			for i, key := range tt.invoices {
+				invoices.AddTokens(&Token{ID: fmt.Sprintf("i-%d", i), Data: key})
+			}
+			join := NewTransition("join", "join")
+			join.AddInputArc(orders, 1)
+			join.AddInputArc(invoices, 1)
+			join.AddOutputArc(matched, 1)
+			join.BindingLimit = tt.limit
+			join.Guard = func(tokens []*Token) bool { return tokens[0].Data == tokens[1].Data }
+			var got string
+			join.Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+				got = fmt.Sprintf("%v/%v", tokens[0].Data, tokens[1].Data)
+				return tokens[:1], nil
+			}
+			net.AddTransition(join)
+
+			err := join.Fire(context.Background())
+			if tt.want == "" {
+				if !errors.Is(err, ErrNotReady) {
+					t.Errorf("Fire() error = %v, want ErrNotReady", err)
+				}
+				if orders.TokenCount() != len(tt.orders) || invoices.TokenCount() != len(tt.invoices) {
+					t.Error("rejected binding consumed tokens")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("bound %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunReportsGuardRejections(t *testing.T) {
+	net := pipeNet("guarded", 2)
+	net.Transitions["move"].Guard = func([]*Token) bool { return false }
+	r := &recorder{}
+	net.AddObserver(r)
+	report, err := net.Run(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.StopReason != StopQuiescent || report.Firings != 0 {
+		t.Errorf("run stopped %s after %d firings, want quiescent after none", report.StopReason, report.Firings)
+	}
+	if r.count("rejected move") == 0 {
+		t.Error("no guard rejection reported to observers")
+	}
+}
+
+func TestUnguardedBindingTakesFirstAvailableTokens(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	tests := []struct {
+		name    string
+		waiting []bool // per token of in: not available yet
+		weights []int  // input arcs on in
+		read    int    // read arc weight on in, 0 = none
+		want    string // tokens bound
+		left    string // tokens left in in after firing
+	}{
+		{"head", []bool{false, false, false}, []int{1}, 0, "t0", "t1 t2"},
+		{"skips waiting tokens", []bool{true, false, true, false}, []int{2}, 0, "t1 t3", "t0 t2"},
+		{"arcs on the same place", []bool{false, true, false, false, false}, []int{2, 1}, 1, "t0 t2 t3 t4", "t1 t4"},
+		{"too few available", []bool{true, false}, []int{2}, 0, "", "t0 t1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := NewPetriNet("bind")
+			net.Clock = NewManualClock(now)
+			in := NewPlace("in", "in", -1)
+			out := NewPlace("out", "out", -1)
+			net.AddPlace(in)
+			net.AddPlace(out)
+			for i, waiting := range tt.waiting {
+				tok := &Token{ID: fmt.Sprintf("t%d", i)}
+				if waiting {
+					tok.AvailableAt = later
+				}
+				in.AddTokens(tok)
+			}
+			tr := NewTransition("take", "take")
+			for _, w := range tt.weights {
+				tr.AddInputArc(in, w)
+			}
+			if tt.read > 0 {
+				tr.AddReadArc(in, tt.read)
+			}
+			tr.AddOutputArc(out, 1)
+			net.AddTransition(tr)
+
+			in.mu.Lock()
+			fast, fastErr := tr.bind(now, nil)
+			searched, searchErr := tr.bind(now, func([]*Token) bool { return true })
+			in.mu.Unlock()
+			if tt.want == "" {
+				if !errors.Is(fastErr, ErrNotReady) || !errors.Is(searchErr, ErrNotReady) {
+					t.Fatalf("bind() errors = %v, %v; want ErrNotReady", fastErr, searchErr)
+				}
+			} else {
+				if fastErr != nil || searchErr != nil {
+					t.Fatalf("bind() errors = %v, %v", fastErr, searchErr)
+				}
+				if got := tokenIDs(fast.tokens); got != tt.want {
+					t.Errorf("bound %s, want %s", got, tt.want)
+				}
+				if got, want := tokenIDs(fast.tokens), tokenIDs(searched.tokens); got != want {
+					t.Errorf("unguarded binding %s differs from the search's %s", got, want)
+				}
+				if err := tr.Fire(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := tokenIDs(net.Snapshot()["in"]); got != tt.left {
+				t.Errorf("in holds %s, want %s", got, tt.left)
+			}
+		})
+	}
+}
+
+func TestRemoveIndices(t *testing.T) {
+	tests := []struct {
+		indices []int
+		want    string
+	}{
+		{nil, "t0 t1 t2 t3 t4"},
+		{[]int{0}, "t1 t2 t3 t4"},
+		{[]int{0, 1}, "t2 t3 t4"},
+		{[]int{2}, "t0 t1 t3 t4"},
+		{[]int{1, 3}, "t0 t2 t4"},
+		{[]int{4}, "t0 t1 t2 t3"},
+		{[]int{0, 1, 2, 3, 4}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.indices), func(t *testing.T) {
+			var queue []*Token
+			for i := 0; i < 5; i++ {
+				queue = append(queue, &Token{ID: fmt.Sprintf("t%d", i)})
+			}
+			if got := tokenIDs(removeIndices(queue, tt.indices)); got != tt.want {
+				t.Errorf("removeIndices() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
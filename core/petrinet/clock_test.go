@@ -0,0 +1,213 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCanFireAndFireUseNetClock(t *testing.T) {
+	// A manual time far from the wall clock, so that using the wall clock
+	// gives the wrong answer either way.
+	start := time.Now().Add(24 * time.Hour)
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    bool
+	}{
+		{"token not available yet", 0, false},
+		{"just before", time.Minute - time.Second, false},
+		{"available", time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			net := NewPetriNet("clock")
+			net.Clock = clock
+			in := NewPlace("in", "in", -1)
+			out := NewPlace("out", "out", -1)
+			net.AddPlace(in)
+			net.AddPlace(out)
+			in.AddTokens(&Token{ID: "late", AvailableAt: start.Add(time.Minute)})
+			tr := NewTransition("move", "move")
+			tr.AddInputArc(in, 1)
+			tr.AddOutputArc(out, 1)
+			net.AddTransition(tr)
+
+			clock.Advance(tt.advance)
+			if got := tr.CanFire(); got != tt.want {
+				t.Errorf("CanFire() = %v, want %v", got, tt.want)
+			}
+			err := tr.Fire(context.Background())
+			if fired := err == nil; fired != tt.want {
+				t.Errorf("Fire() error = %v, want fired %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerReusesTimerForSameDueTime(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	net := NewPetriNet("timers")
+	net.Clock = clock
+
+	// slow waits an hour; fast keeps the scheduler busy meanwhile.
+	slowIn := NewPlace("slow_in", "slow_in", -1)
+	fastIn := NewPlace("fast_in", "fast_in", -1)
+	out := NewPlace("out", "out", -1)
+	for _, p := range []*Place{slowIn, fastIn, out} {
+		net.AddPlace(p)
+	}
+	slowIn.AddTokens(&Token{ID: "slow"})
+	slow := NewTransition("slow", "slow")
+	slow.AddInputArc(slowIn, 1)
+	slow.AddOutputArc(out, 1)
+	slow.Delay = time.Hour
+	net.AddTransition(slow)
+	fast := NewTransition("fast", "fast")
+	fast.AddInputArc(fastIn, 1)
+	fast.AddOutputArc(out, 1)
+	net.AddTransition(fast)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan *RunReport)
+	go func() {
+		report, _ := net.Run(ctx, RunOptions{Continuous: true})
+		done <- report
+	}()
+
+	const pokes = 200
+	for i := 0; i < pokes; i++ {
+		fastIn.AddTokens(&Token{ID: fmt.Sprintf("fast-%d", i)})
+	}
+	waitFor(t, func() bool { return out.TokenCount() == pokes })
+
+	clock.mu.Lock()
+	waiters := len(clock.waiters)
+	clock.mu.Unlock()
+	if waiters > 1 {
+		t.Errorf("%d waiters registered with the clock for one due time, want 1", waiters)
+	}
+
+	clock.Advance(time.Hour)
+	waitFor(t, func() bool { return out.TokenCount() == pokes+1 })
+	cancel()
+	<-done
+}
+
+func TestTimedTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration // of move
+		duration time.Duration // of move
+		started  string        // event after which the minute starts counting
+	}{
+		{"enabling delay", time.Minute, 0, "enabled move"},
+		{"firing duration", 0, time.Minute, "started move"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(time.Now())
+			net := pipeNet("timed", 1)
+			net.Clock = clock
+			move := net.Transitions["move"]
+			move.Delay, move.Duration = tt.delay, tt.duration
+			// next passes the token on as soon as it is available.
+			final := NewPlace("final", "final", -1)
+			net.AddPlace(final)
+			next := NewTransition("next", "next")
+			next.AddInputArc(net.Places["out"], 1)
+			next.AddOutputArc(final, 1)
+			net.AddTransition(next)
+			r := &recorder{}
+			net.AddObserver(r)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				net.Run(ctx, RunOptions{Continuous: true})
+				close(done)
+			}()
+			defer func() { cancel(); <-done }()
+
+			waitFor(t, func() bool { return r.count(tt.started) == 1 })
+			clock.Advance(time.Minute - time.Second)
+			time.Sleep(10 * time.Millisecond)
+			if n := final.TokenCount(); n != 0 {
+				t.Fatal("token passed on a second early")
+			}
+			clock.Advance(time.Second)
+			waitFor(t, func() bool { return final.TokenCount() == 1 })
+		})
+	}
+}
+
+func TestPlaceTracksTimedTokens(t *testing.T) {
+	start := time.Now()
+	clock := NewManualClock(start)
+	net := pipeNet("timed", 0)
+	net.Clock = clock
+	in, move := net.Places["in"], net.Transitions["move"]
+	drain := NewTransition("drain", "drain")
+	drain.AddResetArc(in)
+	drain.AddInputArc(net.Places["out"], 1)
+	net.AddTransition(drain)
+	timed := func(id string) *Token { return &Token{ID: id, AvailableAt: start.Add(time.Minute)} }
+	failing := func(context.Context, []*Token) ([]*Token, error) { return nil, errors.New("failed") }
+
+	steps := []struct {
+		name string
+		do   func() error
+	}{
+		{"add", func() error { return in.AddTokens(timed("a"), &Token{ID: "b"}, timed("c"), &Token{ID: "d"}) }},
+		{"fire", func() error { return move.Fire(context.Background()) }},
+		{"failed fire", func() error {
+			move.Action = failing
+			defer func() { move.Action = nil }()
+			move.Fire(context.Background())
+			return nil
+		}},
+		{"remove", func() error { _, err := in.RemoveTokens(1); return err }},
+		{"restore", func() error {
+			return net.Restore(Marking{"in": {timed("e"), {ID: "f"}, timed("g")}, "out": {{ID: "x"}}})
+		}},
+		{"reset", func() error { return drain.Fire(context.Background()) }},
+		{"add again", func() error { return in.AddTokens(timed("h"), timed("i")) }},
+		{"time passes", func() error { clock.Advance(time.Minute); return move.Fire(context.Background()) }},
+	}
+	for _, step := range steps {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		in.mu.Lock()
+		counted, want := in.timed, countTimed(in.Tokens)
+		in.mu.Unlock()
+		if counted != want {
+			t.Fatalf("after %s the place counts %d timed tokens, holds %d", step.name, counted, want)
+		}
+		available := 0
+		for _, tok := range net.Snapshot()["in"] {
+			if !tok.AvailableAt.After(clock.Now()) {
+				available++
+			}
+		}
+		if n := in.AvailableCount(clock.Now()); n != available {
+			t.Errorf("after %s AvailableCount() = %d, want %d", step.name, n, available)
+		}
+	}
+}
+
+// waitFor polls cond for up to five seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
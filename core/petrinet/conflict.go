@@ -0,0 +1,130 @@
+package petrinet
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// ConflictPolicy decides which of several enabled transitions the scheduler
+// tries next. It matters when they compete for the same tokens or capacity:
+// the transition tried first wins.
+type ConflictPolicy interface {
+	// Choose returns the index in candidates of the transition to try next.
+	// candidates are sorted by ID and never empty; rng is seeded with
+	// RunOptions.Seed in deterministic runs.
+	Choose(candidates []*Transition, rng *rand.Rand) int
+}
+
+// starter is implemented by policies that keep track of the firings they
+// resolved. Started is called when a firing of t starts.
+type starter interface {
+	Started(t *Transition)
+}
+
+var (
+	// StaticPriority tries the transition with the highest Transition.Priority
+	// first, in ID order among equal priorities.
+	StaticPriority ConflictPolicy = staticPriority{}
+	// WeightedRandom draws a transition with probability proportional to its
+	// Transition.Priority; transitions with Priority <= 0 count as 1.
+	WeightedRandom ConflictPolicy = weightedRandom{}
+)
+
+type staticPriority struct{}
+
+func (staticPriority) Choose(candidates []*Transition, rng *rand.Rand) int {
+	best := 0
+	for i, t := range candidates {
+		if t.Priority > candidates[best].Priority {
+			best = i
+		}
+	}
+	return best
+}
+
+type weightedRandom struct{}
+
+func (weightedRandom) Choose(candidates []*Transition, rng *rand.Rand) int {
+	total := 0
+	for _, t := range candidates {
+		total += weight(t)
+	}
+	n := rng.Intn(total)
+	for i, t := range candidates {
+		if n -= weight(t); n < 0 {
+			return i
+		}
+	}
+	return len(candidates) - 1
+}
+
+func weight(t *Transition) int {
+	if t.Priority <= 0 {
+		return 1
+	}
+	return t.Priority
+}
+
+// RoundRobin tries transitions in turn: the first candidate whose ID follows
+// the transition that started last, wrapping around. It is safe for
+// concurrent use, but runs and debuggers sharing one take turns together, so
+// create one per net.
+type RoundRobin struct {
+	mu   sync.Mutex
+	last string
+}
+
+// NewRoundRobin creates a round-robin conflict policy.
+func NewRoundRobin() *RoundRobin {
+	return &RoundRobin{}
+}
+
+func (p *RoundRobin) Choose(candidates []*Transition, rng *rand.Rand) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, t := range candidates {
+		if t.ID > p.last {
+			return i
+		}
+	}
+	return 0
+}
+
+func (p *RoundRobin) Started(t *Transition) {
+	p.mu.Lock()
+	p.last = t.ID
+	p.mu.Unlock()
+}
+
+// LeastRecentlyFired tries the transition that started longest ago first;
+// transitions that never fired come before all others, in ID order. Like
+// RoundRobin it is safe for concurrent use; create one per net.
+type LeastRecentlyFired struct {
+	mu      sync.Mutex
+	clock   uint64
+	started map[string]uint64
+}
+
+// NewLeastRecentlyFired creates a least-recently-fired conflict policy.
+func NewLeastRecentlyFired() *LeastRecentlyFired {
+	return &LeastRecentlyFired{started: make(map[string]uint64)}
+}
+
+func (p *LeastRecentlyFired) Choose(candidates []*Transition, rng *rand.Rand) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	best := 0
+	for i, t := range candidates {
+		if p.started[t.ID] < p.started[candidates[best].ID] {
+			best = i
+		}
+	}
+	return best
+}
+
+func (p *LeastRecentlyFired) Started(t *Transition) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock++
+	p.started[t.ID] = p.clock
+}
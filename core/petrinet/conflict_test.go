@@ -0,0 +1,59 @@
+package petrinet
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestStatefulPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy func() ConflictPolicy
+		want   string // IDs tried when a, b and c are always enabled
+	}{
+		{"round robin", func() ConflictPolicy { return NewRoundRobin() }, "abcabca"},
+		{"least recently fired", func() ConflictPolicy { return NewLeastRecentlyFired() }, "abcabca"},
+		{"static priority", func() ConflictPolicy { return StaticPriority }, "aaaaaaa"},
+	}
+	candidates := []*Transition{NewTransition("a", "a"), NewTransition("b", "b"), NewTransition("c", "c")}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy()
+			var got string
+			for range tt.want {
+				tr := candidates[policy.Choose(candidates, nil)]
+				if st, ok := policy.(starter); ok {
+					st.Started(tr)
+				}
+				got += tr.ID
+			}
+			if got != tt.want {
+				t.Errorf("tried %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSharedPolicyConcurrentRuns shares one stateful policy between nets
+// running concurrently; run it with -race.
+func TestSharedPolicyConcurrentRuns(t *testing.T) {
+	for _, policy := range []ConflictPolicy{NewRoundRobin(), NewLeastRecentlyFired()} {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			net := conflictNet(30)
+			net.Conflict = policy
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := net.Run(context.Background(), RunOptions{MaxConcurrency: 4}); err != nil {
+					t.Error(err)
+				}
+				if n := net.Places["done"].TokenCount(); n != 30 {
+					t.Errorf("done holds %d tokens, want 30", n)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,372 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNothingToUndo is returned by Debugger.Undo when no firing is left to undo.
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrMarkingChanged is returned by Debugger.Undo when tokens were added to
+	// or removed from the net since the firing to undo, e.g. with AddTokens.
+	ErrMarkingChanged = errors.New("marking changed since the firing")
+)
+
+// DefaultMaxUndo is the number of firings a debugger can undo when MaxUndo is not set.
+const DefaultMaxUndo = 100
+
+// Condition compares the token count of a place with a number, e.g. "results > 5".
+type Condition struct {
+	Place string
+	Op    string // One of >, >=, <, <=, ==, !=
+	Count int
+}
+
+// ParseCondition parses "<place> <op> <count>", with or without spaces.
+func ParseCondition(expr string) (Condition, error) {
+	expr = strings.TrimSpace(expr)
+	for _, op := range []string{">=", "<=", "==", "!=", ">", "<"} {
+		i := strings.Index(expr, op)
+		if i < 0 {
+			continue
+		}
+		place := strings.TrimSpace(expr[:i])
+		count, err := strconv.Atoi(strings.TrimSpace(expr[i+len(op):]))
+		if place == "" || err != nil {
+			break
+		}
+		return Condition{Place: place, Op: op, Count: count}, nil
+	}
+	return Condition{}, fmt.Errorf("invalid condition %q (want e.g. \"results > 5\")", expr)
+}
+
+// Holds evaluates the condition on token counts per place ID.
+func (c Condition) Holds(counts map[string]int) bool {
+	n := counts[c.Place]
+	switch c.Op {
+	case ">":
+		return n > c.Count
+	case ">=":
+		return n >= c.Count
+	case "<":
+		return n < c.Count
+	case "<=":
+		return n <= c.Count
+	case "==":
+		return n == c.Count
+	case "!=":
+		return n != c.Count
+	}
+	return false
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %d", c.Place, c.Op, c.Count)
+}
+
+// Breakpoint stops Debugger.Step either before a transition fires or after a
+// firing makes a place condition true.
+type Breakpoint struct {
+	ID         int
+	Transition string     // Stop before this transition fires
+	Condition  *Condition // Stop once a firing turns the condition from false to true
+	Hits       int
+}
+
+func (b *Breakpoint) String() string {
+	if b.Condition != nil {
+		return fmt.Sprintf("#%d when %s (%d hits)", b.ID, b.Condition, b.Hits)
+	}
+	return fmt.Sprintf("#%d before %s (%d hits)", b.ID, b.Transition, b.Hits)
+}
+
+// Debugger fires the transitions of a net one at a time under user control.
+// Every firing is preceded by a snapshot, so it can be undone. A debugger
+// must not be used while the net runs.
+type Debugger struct {
+	MaxUndo int // Firings kept for Undo, oldest dropped first (0 = DefaultMaxUndo)
+
+	net    *PetriNet
+	clock  Clock
+	rng    *rand.Rand
+	policy ConflictPolicy
+	report *RunReport
+
+	undo        []debugFrame
+	breakpoints []*Breakpoint
+	nextID      int
+	held        *Transition // chosen by Step but stopped at its breakpoint
+}
+
+// debugFrame is one firing made by the debugger, the marking before it and
+// the serials of the tokens in each place after it.
+type debugFrame struct {
+	before Marking
+	after  map[string][]uint64
+	event  FiringEvent
+}
+
+// NewDebugger creates a debugger for net. Step resolves conflicts with the
+// net's ConflictPolicy, drawing from a source seeded with seed; when the net
+// has none, the least recently fired transition goes first so that an always
+// enabled source does not starve the rest.
+func NewDebugger(net *PetriNet, seed int64) *Debugger {
+	policy := net.Conflict
+	if policy == nil {
+		policy = NewLeastRecentlyFired()
+	}
+	return &Debugger{
+		net:    net,
+		clock:  net.clock(),
+		rng:    rand.New(rand.NewSource(seed)),
+		policy: policy,
+		report: newRunReport(net.Name),
+		nextID: 1,
+	}
+}
+
+// Enabled returns the transitions that can fire now, guards included, in ID
+// order. Enabling delays are ignored.
+func (d *Debugger) Enabled() []*Transition {
+	now := d.clock.Now()
+	d.net.mu.RLock()
+	defer d.net.mu.RUnlock()
+
+	var enabled []*Transition
+	for _, t := range d.net.Transitions {
+		if t.fireable(now) {
+			enabled = append(enabled, t)
+		}
+	}
+	sort.Slice(enabled, func(i, j int) bool { return enabled[i].ID < enabled[j].ID })
+	return enabled
+}
+
+// Fire fires the transition with the given ID and waits for it to finish,
+// ignoring breakpoints. A failed action is rolled back and not recorded for undo.
+func (d *Debugger) Fire(ctx context.Context, id string) (FiringEvent, error) {
+	d.net.mu.RLock()
+	t, ok := d.net.Transitions[id]
+	d.net.mu.RUnlock()
+	if !ok {
+		return FiringEvent{}, fmt.Errorf("unknown transition %s", id)
+	}
+	return d.fire(ctx, t)
+}
+
+func (d *Debugger) fire(ctx context.Context, t *Transition) (FiringEvent, error) {
+	d.held = nil
+	before := d.net.Snapshot()
+	f, err := t.reserve(d.clock.Now())
+	if err != nil {
+		return FiringEvent{}, fmt.Errorf("%s cannot fire: %w", t.ID, err)
+	}
+	if st, ok := d.policy.(starter); ok {
+		st.Started(t)
+	}
+	startEv := f.event()
+	d.net.eachObserver(func(o Observer) { o.OnFiringStarted(startEv) })
+
+	ev := f.run(ctx)
+	if ev.Err != nil {
+		d.net.complete(ev, d.report)
+		return ev, ev.Err
+	}
+	d.undo = append(d.undo, debugFrame{before: before, after: tokenSerials(d.net.Snapshot()), event: ev})
+	limit := d.MaxUndo
+	if limit <= 0 {
+		limit = DefaultMaxUndo
+	}
+	if len(d.undo) > limit {
+		d.undo = append([]debugFrame(nil), d.undo[len(d.undo)-limit:]...)
+	}
+	return ev, d.net.complete(ev, d.report)
+}
+
+// Step fires up to n transitions, choosing among the enabled ones like the
+// scheduler would. It returns the firings made and the breakpoint that stopped
+// it, if any; fewer than n firings without a breakpoint means nothing was
+// enabled. A transition held at its breakpoint fires first on the next Step.
+func (d *Debugger) Step(ctx context.Context, n int) ([]FiringEvent, *Breakpoint, error) {
+	var fired []FiringEvent
+	for len(fired) < n {
+		if err := ctx.Err(); err != nil {
+			return fired, nil, err
+		}
+		t := d.held
+		if t == nil || !t.fireable(d.clock.Now()) {
+			enabled := d.Enabled()
+			if len(enabled) == 0 {
+				return fired, nil, nil
+			}
+			t = enabled[d.policy.Choose(enabled, d.rng)]
+			if bp := d.transitionBreakpoint(t); bp != nil {
+				bp.Hits++
+				d.held = t
+				return fired, bp, nil
+			}
+		}
+
+		before := d.net.tokenCounts()
+		ev, err := d.fire(ctx, t)
+		if err != nil {
+			return fired, nil, err
+		}
+		fired = append(fired, ev)
+		if bp := d.conditionBreakpoint(before, d.net.tokenCounts()); bp != nil {
+			bp.Hits++
+			return fired, bp, nil
+		}
+	}
+	return fired, nil, nil
+}
+
+func (d *Debugger) transitionBreakpoint(t *Transition) *Breakpoint {
+	for _, bp := range d.breakpoints {
+		if bp.Transition == t.ID {
+			return bp
+		}
+	}
+	return nil
+}
+
+func (d *Debugger) conditionBreakpoint(before, after map[string]int) *Breakpoint {
+	for _, bp := range d.breakpoints {
+		if bp.Condition != nil && !bp.Condition.Holds(before) && bp.Condition.Holds(after) {
+			return bp
+		}
+	}
+	return nil
+}
+
+// Undo restores the marking before the last firing made by the debugger.
+// Side effects of the action and run statistics are not undone. Undo refuses
+// with ErrMarkingChanged when the net no longer holds exactly the tokens the
+// firing left, since restoring would silently drop tokens added afterwards.
+func (d *Debugger) Undo() (FiringEvent, error) {
+	if len(d.undo) == 0 {
+		return FiringEvent{}, ErrNothingToUndo
+	}
+	frame := d.undo[len(d.undo)-1]
+	if changed := changedPlaces(frame.after, tokenSerials(d.net.Snapshot())); len(changed) > 0 {
+		return FiringEvent{}, fmt.Errorf("cannot undo %s: %w in %s", frame.event.Transition.ID, ErrMarkingChanged, strings.Join(changed, ", "))
+	}
+	if err := d.net.Restore(frame.before); err != nil {
+		return FiringEvent{}, err
+	}
+	d.undo = d.undo[:len(d.undo)-1]
+	d.held = nil
+	return frame.event, nil
+}
+
+// changedPlaces returns the IDs of the places whose tokens differ between two
+// markings given as serials, in ID order.
+func changedPlaces(a, b map[string][]uint64) []string {
+	var changed []string
+	for id := range a {
+		if !sameSerials(a[id], b[id]) {
+			changed = append(changed, id)
+		}
+	}
+	for id := range b {
+		if _, ok := a[id]; !ok && len(b[id]) > 0 {
+			changed = append(changed, id)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// sameSerials reports whether a and b hold the same serials in any order.
+func sameSerials(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[uint64]int, len(a))
+	for _, s := range a {
+		count[s]++
+	}
+	for _, s := range b {
+		if count[s]--; count[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// History returns the firings that can be undone, oldest first.
+func (d *Debugger) History() []FiringEvent {
+	events := make([]FiringEvent, len(d.undo))
+	for i, frame := range d.undo {
+		events[i] = frame.event
+	}
+	return events
+}
+
+// Report returns the statistics of all firings made by the debugger.
+func (d *Debugger) Report() *RunReport {
+	d.report.FinalMarking = d.net.tokenCounts()
+	d.report.Elapsed = time.Since(d.report.StartedAt)
+	return d.report
+}
+
+// BreakOnTransition stops Step before the transition fires.
+func (d *Debugger) BreakOnTransition(id string) (*Breakpoint, error) {
+	d.net.mu.RLock()
+	_, ok := d.net.Transitions[id]
+	d.net.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown transition %s", id)
+	}
+	return d.addBreakpoint(&Breakpoint{Transition: id}), nil
+}
+
+// BreakWhen stops Step after a firing that makes the condition true.
+func (d *Debugger) BreakWhen(c Condition) (*Breakpoint, error) {
+	d.net.mu.RLock()
+	_, ok := d.net.Places[c.Place]
+	d.net.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown place %s", c.Place)
+	}
+	return d.addBreakpoint(&Breakpoint{Condition: &c}), nil
+}
+
+func (d *Debugger) addBreakpoint(bp *Breakpoint) *Breakpoint {
+	bp.ID = d.nextID
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, bp)
+	return bp
+}
+
+// Breakpoints returns the breakpoints in the order they were set.
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	return append([]*Breakpoint(nil), d.breakpoints...)
+}
+
+// DeleteBreakpoint removes the breakpoint with the given ID.
+func (d *Debugger) DeleteBreakpoint(id int) error {
+	for i, bp := range d.breakpoints {
+		if bp.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no breakpoint #%d", id)
+}
+
+// Tokens returns copies of the tokens in a place, in queue order.
+func (d *Debugger) Tokens(placeID string) ([]*Token, error) {
+	tokens, ok := d.net.Snapshot()[placeID]
+	if !ok {
+		return nil, fmt.Errorf("unknown place %s", placeID)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,133 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDebuggerUndo(t *testing.T) {
+	tests := []struct {
+		name    string
+		between func(net *PetriNet) // after the firing, before Undo
+		wantErr error
+		wantIn  int // tokens in in after a successful Undo
+	}{
+		{"restores the marking", func(net *PetriNet) {}, nil, 3},
+		{"tokens added since", func(net *PetriNet) {
+			net.Places["in"].AddTokens(&Token{ID: "late"})
+		}, ErrMarkingChanged, 0},
+		{"tokens removed since", func(net *PetriNet) {
+			net.Places["out"].RemoveTokens(1)
+		}, ErrMarkingChanged, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := pipeNet("debug", 3)
+			d := NewDebugger(net, 1)
+			if _, err := d.Fire(context.Background(), "move"); err != nil {
+				t.Fatal(err)
+			}
+			tt.between(net)
+			before := net.tokenCounts()
+
+			_, err := d.Undo()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Undo() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if got := net.tokenCounts(); got["in"] != before["in"] || got["out"] != before["out"] {
+					t.Errorf("refused Undo changed the marking from %v to %v", before, got)
+				}
+				if len(d.History()) != 1 {
+					t.Errorf("refused Undo dropped the firing from the history")
+				}
+				return
+			}
+			if n := net.Places["in"].TokenCount(); n != tt.wantIn {
+				t.Errorf("in holds %d tokens, want %d", n, tt.wantIn)
+			}
+		})
+	}
+}
+
+func TestDebuggerUndoAfterLaterFiring(t *testing.T) {
+	// Tokens added before a firing are part of its snapshot, so it undoes.
+	net := pipeNet("debug", 1)
+	d := NewDebugger(net, 1)
+	if _, err := d.Fire(context.Background(), "move"); err != nil {
+		t.Fatal(err)
+	}
+	net.Places["in"].AddTokens(&Token{ID: "late"})
+	if _, err := d.Fire(context.Background(), "move"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Undo(); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if n := net.Places["in"].TokenCount(); n != 1 {
+		t.Errorf("in holds %d tokens, want the late token back", n)
+	}
+	if _, err := d.Undo(); !errors.Is(err, ErrMarkingChanged) {
+		t.Errorf("Undo() past the added token error = %v, want ErrMarkingChanged", err)
+	}
+}
+
+func TestDebuggerStepBreakpoints(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(d *Debugger) error
+		want  []int // firings of consecutive Steps
+		hits  []bool
+	}{
+		{"no breakpoints", func(d *Debugger) error { return nil }, []int{3, 0}, []bool{false, false}},
+		{"before a transition", func(d *Debugger) error {
+			_, err := d.BreakOnTransition("move")
+			return err
+		}, []int{0, 1, 1}, []bool{true, true, true}},
+		{"when a condition becomes true", func(d *Debugger) error {
+			_, err := d.BreakWhen(Condition{Place: "out", Op: ">=", Count: 2})
+			return err
+		}, []int{2, 1}, []bool{true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDebugger(pipeNet("debug", 3), 1)
+			if err := tt.setup(d); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				fired, bp, err := d.Step(context.Background(), 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(fired) != want || (bp != nil) != tt.hits[i] {
+					t.Errorf("step %d fired %d, breakpoint %v; want %d, hit %v", i+1, len(fired), bp, want, tt.hits[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    Condition
+		wantErr bool
+	}{
+		{"results > 5", Condition{"results", ">", 5}, false},
+		{"results>=5", Condition{"results", ">=", 5}, false},
+		{" queue != 0 ", Condition{"queue", "!=", 0}, false},
+		{"results >", Condition{}, true},
+		{"> 5", Condition{}, true},
+		{"results", Condition{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseCondition(tt.expr)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseCondition() = %v, %v; want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
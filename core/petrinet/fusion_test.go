@@ -0,0 +1,125 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// pipeNet builds a net moving tokens from in to out.
+func pipeNet(name string, tokens int) *PetriNet {
+	net := NewPetriNet(name)
+	in := NewPlace("in", "in", -1)
+	out := NewPlace("out", "out", -1)
+	net.AddPlace(in)
+	net.AddPlace(out)
+	for i := 0; i < tokens; i++ {
+		in.AddTokens(&Token{ID: fmt.Sprintf("%s-%d", name, i)})
+	}
+	t := NewTransition("move", "move")
+	t.AddInputArc(in, 1)
+	t.AddOutputArc(out, 1)
+	net.AddTransition(t)
+	return net
+}
+
+func TestFusionSetExchangesTokensBetweenRunningNets(t *testing.T) {
+	const docs = 200
+	producer := pipeNet("ingest", docs)
+	consumer := pipeNet("summarize", 0)
+	shared := NewFusionSet("documents", "documents", 10)
+	if err := shared.Join(producer, "out"); err != nil {
+		t.Fatal(err)
+	}
+	if err := shared.Join(consumer, "in"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both nets run continuously: a run that is not continuous ends as soon as
+	// the full shared place blocks it, before the other net drains it.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{}, 2)
+	for _, net := range []*PetriNet{producer, consumer} {
+		go func(net *PetriNet) {
+			net.Run(ctx, RunOptions{Continuous: true, MaxConcurrency: 4})
+			done <- struct{}{}
+		}(net)
+	}
+	waitFor(t, func() bool { return consumer.Places["out"].TokenCount() == docs })
+	cancel()
+	<-done
+	<-done
+
+	if n := shared.Place.TokenCount(); n != 0 {
+		t.Errorf("shared place holds %d tokens, want 0", n)
+	}
+	if _, ok := producer.Places["out"]; ok {
+		t.Error("producer still has its own out place")
+	}
+	if producer.Places["documents"] != consumer.Places["documents"] {
+		t.Error("nets do not share the documents place")
+	}
+}
+
+func TestFusionSetJoin(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  int // tokens in the joining place
+		running bool
+		wantErr error
+	}{
+		{"moves tokens", 3, false, nil},
+		{"over capacity", 6, false, nil},
+		{"while running", 0, true, ErrNetRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := pipeNet("net", 0)
+			for i := 0; i < tt.tokens; i++ {
+				net.Places["out"].AddTokens(&Token{ID: fmt.Sprintf("t-%d", i)})
+			}
+			shared := NewFusionSet("shared", "shared", 5)
+
+			if tt.running {
+				ctx, cancel := context.WithCancel(context.Background())
+				done := make(chan struct{})
+				go func() {
+					net.Run(ctx, RunOptions{Continuous: true})
+					close(done)
+				}()
+				waitFor(t, func() bool {
+					net.mu.RLock()
+					defer net.mu.RUnlock()
+					return net.running > 0
+				})
+				defer func() { cancel(); <-done }()
+			}
+
+			err := shared.Join(net, "out")
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Join() error = %v, want %v", err, tt.wantErr)
+				}
+				if _, ok := net.Places["out"]; !ok {
+					t.Error("failed Join removed the place")
+				}
+			case tt.tokens > shared.Place.Capacity:
+				if err == nil {
+					t.Fatal("Join() over capacity succeeded")
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n := shared.Place.TokenCount(); n != tt.tokens {
+					t.Errorf("shared place holds %d tokens, want %d", n, tt.tokens)
+				}
+				if got := net.Transitions["move"].OutputArcs[0].Place; got != shared.Place {
+					t.Errorf("output arc points at %s, want the shared place", got.ID)
+				}
+			}
+		})
+	}
+}
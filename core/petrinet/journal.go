@@ -0,0 +1,570 @@
+package petrinet
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSegmentBytes is the size after which a journal segment is rotated.
+const DefaultMaxSegmentBytes = 4 << 20
+
+const (
+	segmentPrefix  = "segment-"
+	segmentSuffix  = ".jsonl"
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".json"
+)
+
+// JournalEntry records one finished firing. Consumed, Read and Reset list
+// token serials per place ID, since token IDs need not be unique; Produced
+// holds the full tokens, including data and serials.
+//
+// External entries record a change made outside any firing by AddTokens,
+// RemoveTokens or Restore: the tokens removed from the place in Consumed and
+// those added in Produced. They have no Transition.
+type JournalEntry struct {
+	Seq        uint64
+	Time       time.Time // When the firing finished
+	External   bool
+	Transition string
+	Consumed   map[string][]uint64
+	Read       map[string][]uint64
+	Reset      map[string][]uint64
+	Produced   map[string][]*Token
+	Duration   time.Duration // Time spent in the action
+	Err        string        // Empty for committed firings; failed firings were rolled back
+}
+
+// Committed reports whether the firing changed the marking.
+func (e *JournalEntry) Committed() bool {
+	return e.Err == ""
+}
+
+// name names the transition of e, or the kind of external entries, in errors.
+func (e *JournalEntry) name() string {
+	if e.External {
+		return "external"
+	}
+	return e.Transition
+}
+
+type journalRecord struct {
+	Seq        uint64                   `json:"seq"`
+	Time       time.Time                `json:"time"`
+	External   bool                     `json:"external,omitempty"`
+	Transition string                   `json:"transition,omitempty"`
+	Consumed   map[string][]uint64      `json:"consumed,omitempty"`
+	Read       map[string][]uint64      `json:"read,omitempty"`
+	Reset      map[string][]uint64      `json:"reset,omitempty"`
+	Produced   map[string][]storedToken `json:"produced,omitempty"`
+	Duration   time.Duration            `json:"duration"`
+	Err        string                   `json:"error,omitempty"`
+}
+
+// Journal is an append-only record of firings, stored in a directory as
+// JSON-lines segments ("segment-<first seq>.jsonl") next to snapshots of the
+// marking ("snapshot-<seq>.json", the marking after entry seq). It is an
+// Observer: add it to a net to record every firing of its runs and every
+// external change to its marking.
+//
+// A journal starts from a snapshot of the initial marking (WriteSnapshot).
+// Compact folds closed segments into a newer snapshot so that the directory
+// does not grow forever.
+type Journal struct {
+	BaseObserver
+
+	Dir             string
+	Codec           Codec // nil = JSONCodec
+	MaxSegmentBytes int64 // 0 = DefaultMaxSegmentBytes
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	seq  uint64 // last appended sequence number
+	err  error  // first error while observing
+}
+
+// OpenJournal opens the journal in dir, creating the directory if needed, and
+// continues numbering after its last entry.
+func OpenJournal(dir string, codec Codec) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	j := &Journal{Dir: dir, Codec: codec}
+
+	snapshots, segments, err := j.files()
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) > 0 {
+		j.seq = snapshots[len(snapshots)-1].seq
+	}
+	if len(segments) > 0 {
+		entries, err := j.readSegment(segments[len(segments)-1].path)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 && entries[len(entries)-1].Seq > j.seq {
+			j.seq = entries[len(entries)-1].Seq
+		}
+	}
+	return j, nil
+}
+
+func (j *Journal) codec() Codec {
+	if j.Codec == nil {
+		return JSONCodec{}
+	}
+	return j.Codec
+}
+
+// Err returns the first error the journal hit while recording firings as an
+// observer, since observer callbacks cannot fail.
+func (j *Journal) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+func (j *Journal) OnFiringCompleted(ev FiringEvent) { j.observe(j.Append(ev)) }
+func (j *Journal) OnFiringFailed(ev FiringEvent)    { j.observe(j.Append(ev)) }
+
+func (j *Journal) OnExternalChange(p *Place, added, removed []*Token) {
+	j.observe(j.AppendExternal(p.ID, added, removed))
+}
+
+func (j *Journal) observe(err error) {
+	if err != nil {
+		j.mu.Lock()
+		if j.err == nil {
+			j.err = err
+		}
+		j.mu.Unlock()
+	}
+}
+
+// AppendExternal records tokens added to and removed from place outside any
+// firing.
+func (j *Journal) AppendExternal(place string, added, removed []*Token) error {
+	rec := journalRecord{
+		Time:     time.Now(),
+		External: true,
+		Consumed: tokenSerials(map[string][]*Token{place: removed}),
+	}
+	if len(rec.Consumed[place]) == 0 {
+		rec.Consumed = nil
+	}
+	if len(added) > 0 {
+		stored, err := encodeTokens(j.codec(), added)
+		if err != nil {
+			return fmt.Errorf("journal %s: encode tokens: %w", place, err)
+		}
+		rec.Produced = map[string][]storedToken{place: stored}
+	}
+	return j.write(rec)
+}
+
+// Append records a finished firing and rotates the segment once it exceeds
+// MaxSegmentBytes.
+func (j *Journal) Append(ev FiringEvent) error {
+	rec := journalRecord{
+		Time:       time.Now(),
+		Transition: ev.Transition.ID,
+		Consumed:   tokenSerials(ev.Consumed),
+		Read:       tokenSerials(ev.Read),
+		Reset:      tokenSerials(ev.Reset),
+		Duration:   ev.Duration,
+	}
+	if ev.Err != nil {
+		rec.Err = ev.Err.Error()
+	} else if len(ev.Produced) > 0 {
+		rec.Produced = make(map[string][]storedToken, len(ev.Produced))
+		for id, tokens := range ev.Produced {
+			stored, err := encodeTokens(j.codec(), tokens)
+			if err != nil {
+				return fmt.Errorf("journal %s: encode tokens of %s: %w", ev.Transition.ID, id, err)
+			}
+			rec.Produced[id] = stored
+		}
+	}
+	return j.write(rec)
+}
+
+// write appends rec with the next sequence number.
+func (j *Journal) write(rec journalRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	rec.Seq = j.seq + 1
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if j.file == nil {
+		if err := j.openSegment(rec.Seq); err != nil {
+			return err
+		}
+	}
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("journal append: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("journal append: %w", err)
+	}
+	j.seq = rec.Seq
+
+	limit := j.MaxSegmentBytes
+	if limit <= 0 {
+		limit = DefaultMaxSegmentBytes
+	}
+	if j.size >= limit {
+		return j.closeSegment()
+	}
+	return nil
+}
+
+func tokenSerials(perPlace map[string][]*Token) map[string][]uint64 {
+	if len(perPlace) == 0 {
+		return nil
+	}
+	serials := make(map[string][]uint64, len(perPlace))
+	for place, tokens := range perPlace {
+		for _, tok := range tokens {
+			serials[place] = append(serials[place], tok.Serial)
+		}
+	}
+	return serials
+}
+
+// openSegment starts a new segment whose first entry is seq. Callers must hold j.mu.
+func (j *Journal) openSegment(seq uint64) error {
+	path := filepath.Join(j.Dir, fmt.Sprintf("%s%020d%s", segmentPrefix, seq, segmentSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	j.file, j.size = f, info.Size()
+	return nil
+}
+
+// closeSegment closes the open segment; the next append starts a new one.
+// Callers must hold j.mu.
+func (j *Journal) closeSegment() error {
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file, j.size = nil, 0
+	return err
+}
+
+// Rotate closes the current segment so that the next firing starts a new one.
+func (j *Journal) Rotate() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.closeSegment()
+}
+
+// Close closes the journal.
+func (j *Journal) Close() error {
+	return j.Rotate()
+}
+
+// WriteSnapshot records m as the marking after the last appended entry. Call it
+// with the initial marking before the first run, while no firing is in flight.
+func (j *Journal) WriteSnapshot(m Marking) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.writeSnapshot(j.seq, m)
+}
+
+func (j *Journal) writeSnapshot(seq uint64, m Marking) error {
+	path := filepath.Join(j.Dir, fmt.Sprintf("%s%020d%s", snapshotPrefix, seq, snapshotSuffix))
+	return NewFileStore(path, j.codec()).Save(m)
+}
+
+// Compact replays the closed segments onto the latest snapshot, writes the
+// result as a new snapshot and deletes the segments and older snapshots it
+// replaces. The open segment is rotated first, so everything recorded so far
+// is compacted. Compaction does not know place orderings: produced tokens are
+// appended in commit order.
+func (j *Journal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.closeSegment(); err != nil {
+		return err
+	}
+
+	m, seq, err := j.replay(0, nil)
+	if err != nil {
+		return err
+	}
+	if err := j.writeSnapshot(seq, m); err != nil {
+		return err
+	}
+
+	snapshots, segments, err := j.files()
+	if err != nil {
+		return err
+	}
+	for _, s := range snapshots {
+		if s.seq < seq {
+			if err := os.Remove(s.path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, s := range segments {
+		if s.seq <= seq {
+			if err := os.Remove(s.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Entries returns every entry still in the journal, in sequence order.
+func (j *Journal) Entries() ([]*JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, segments, err := j.files()
+	if err != nil {
+		return nil, err
+	}
+	var entries []*JournalEntry
+	for _, s := range segments {
+		seg, err := j.readSegment(s.path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, seg...)
+	}
+	return entries, nil
+}
+
+// History returns the entries that consumed, read, reset or produced the token
+// with the given serial (see Token.Serial), including the external entries
+// that added or removed it, which is how a single ticket is audited.
+func (j *Journal) History(serial uint64) ([]*JournalEntry, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var history []*JournalEntry
+	for _, e := range entries {
+		if e.touches(serial) {
+			history = append(history, e)
+		}
+	}
+	return history, nil
+}
+
+func (e *JournalEntry) touches(serial uint64) bool {
+	for _, perPlace := range []map[string][]uint64{e.Consumed, e.Read, e.Reset} {
+		for _, serials := range perPlace {
+			for _, s := range serials {
+				if s == serial {
+					return true
+				}
+			}
+		}
+	}
+	for _, tokens := range e.Produced {
+		for _, tok := range tokens {
+			if tok.Serial == serial {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type journalFile struct {
+	path string
+	seq  uint64
+}
+
+// files lists the snapshots and segments of the journal in sequence order.
+func (j *Journal) files() (snapshots, segments []journalFile, err error) {
+	dirEntries, err := os.ReadDir(j.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, de := range dirEntries {
+		name := de.Name()
+		for _, kind := range []struct {
+			prefix, suffix string
+			list           *[]journalFile
+		}{
+			{snapshotPrefix, snapshotSuffix, &snapshots},
+			{segmentPrefix, segmentSuffix, &segments},
+		} {
+			if !strings.HasPrefix(name, kind.prefix) || !strings.HasSuffix(name, kind.suffix) {
+				continue
+			}
+			seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, kind.prefix), kind.suffix), 10, 64)
+			if err != nil {
+				continue
+			}
+			*kind.list = append(*kind.list, journalFile{path: filepath.Join(j.Dir, name), seq: seq})
+		}
+	}
+	sort.Slice(snapshots, func(a, b int) bool { return snapshots[a].seq < snapshots[b].seq })
+	sort.Slice(segments, func(a, b int) bool { return segments[a].seq < segments[b].seq })
+	return snapshots, segments, nil
+}
+
+func (j *Journal) readSegment(path string) ([]*JournalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*JournalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn last line is what a crash during append leaves behind.
+			if !scanner.Scan() {
+				break
+			}
+			return nil, fmt.Errorf("journal segment %s: %w", path, err)
+		}
+		entry := &JournalEntry{
+			Seq:        rec.Seq,
+			Time:       rec.Time,
+			External:   rec.External,
+			Transition: rec.Transition,
+			Consumed:   rec.Consumed,
+			Read:       rec.Read,
+			Reset:      rec.Reset,
+			Duration:   rec.Duration,
+			Err:        rec.Err,
+		}
+		if len(rec.Produced) > 0 {
+			entry.Produced = make(map[string][]*Token, len(rec.Produced))
+			for id, stored := range rec.Produced {
+				tokens, err := decodeTokens(j.codec(), stored)
+				if err != nil {
+					return nil, fmt.Errorf("journal entry %d: decode tokens of %s: %w", rec.Seq, id, err)
+				}
+				entry.Produced[id] = tokens
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// ErrNoSnapshot is returned when a journal has no snapshot to replay from.
+var ErrNoSnapshot = errors.New("journal has no snapshot")
+
+// base loads the latest snapshot. Callers must hold j.mu.
+func (j *Journal) base() (Marking, uint64, error) {
+	snapshots, _, err := j.files()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(snapshots) == 0 {
+		return nil, 0, ErrNoSnapshot
+	}
+	latest := snapshots[len(snapshots)-1]
+	m, err := NewFileStore(latest.path, j.codec()).Load()
+	if err != nil {
+		return nil, 0, err
+	}
+	return m, latest.seq, nil
+}
+
+// replay applies the committed entries after the latest snapshot, up to and
+// including until (0 = all), to that snapshot without running any action.
+// Produced tokens are inserted with the ordering of the place in net, or
+// appended when net is nil. Callers must hold j.mu.
+func (j *Journal) replay(until uint64, net *PetriNet) (Marking, uint64, error) {
+	m, seq, err := j.base()
+	if err != nil {
+		return nil, 0, err
+	}
+	_, segments, err := j.files()
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, s := range segments {
+		entries, err := j.readSegment(s.path)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, e := range entries {
+			if e.Seq <= seq {
+				continue
+			}
+			if until > 0 && e.Seq > until {
+				return m, seq, nil
+			}
+			if e.Committed() {
+				if err := m.apply(e, net); err != nil {
+					return nil, 0, err
+				}
+			}
+			seq = e.Seq
+		}
+	}
+	return m, seq, nil
+}
+
+// apply removes the consumed and reset tokens of e and inserts its produced tokens.
+func (m Marking) apply(e *JournalEntry, net *PetriNet) error {
+	for _, perPlace := range []map[string][]uint64{e.Consumed, e.Reset} {
+		for place, serials := range perPlace {
+			for _, serial := range serials {
+				idx := -1
+				for i, tok := range m[place] {
+					if tok.Serial == serial {
+						idx = i
+						break
+					}
+				}
+				if idx < 0 {
+					return fmt.Errorf("journal entry %d (%s): token #%d not in %s", e.Seq, e.name(), serial, place)
+				}
+				m[place] = append(m[place][:idx:idx], m[place][idx+1:]...)
+			}
+		}
+	}
+	for place, tokens := range e.Produced {
+		ordering := Ordering(FIFO)
+		if net != nil {
+			if p, ok := net.Places[place]; ok && p.Ordering != nil {
+				ordering = p.Ordering
+			}
+		}
+		m[place] = ordering.Insert(m[place], copyTokens(tokens)...)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// duplicateIDNet builds a net whose tokens all share the ID "doc": process
+// turns every input into an output with the same ID, and drop removes the
+// processed "b" from the middle of the queue.
+func duplicateIDNet() *PetriNet {
+	net := NewPetriNet("journal")
+	in := NewPlace("in", "in", -1)
+	out := NewPlace("out", "out", -1)
+	trash := NewPlace("trash", "trash", -1)
+	for _, p := range []*Place{in, out, trash} {
+		net.AddPlace(p)
+	}
+	for _, data := range []string{"a", "b", "c"} {
+		in.AddTokens(&Token{ID: "doc", Data: data})
+	}
+
+	process := NewTransition("process", "process")
+	process.AddInputArc(in, 1)
+	process.AddOutputArc(out, 1)
+	process.Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+		return []*Token{{ID: "doc", Data: tokens[0].Data.(string) + "-processed"}}, nil
+	}
+	net.AddTransition(process)
+
+	drop := NewTransition("drop", "drop")
+	drop.AddInputArc(out, 1)
+	drop.AddOutputArc(trash, 1)
+	drop.Guard = func(tokens []*Token) bool { return tokens[0].Data == "b-processed" }
+	net.AddTransition(drop)
+	return net
+}
+
+// describe lists the tokens of every place as "serial:data", sorted by serial
+// unless ordered is set.
+func describe(m Marking, ordered bool) map[string][]string {
+	described := make(map[string][]string, len(m))
+	for place, tokens := range m {
+		tokens = append([]*Token(nil), tokens...)
+		if !ordered {
+			sort.Slice(tokens, func(i, j int) bool { return tokens[i].Serial < tokens[j].Serial })
+		}
+		for _, tok := range tokens {
+			described[place] = append(described[place], fmt.Sprintf("%d:%v", tok.Serial, tok.Data))
+		}
+	}
+	return described
+}
+
+func TestJournalReplayRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		sequential bool // concurrent commits may reach FIFO places in another order than journaled
+		execute    bool
+	}{
+		{"recorded outputs", true, false},
+		{"re-executed", true, true},
+		{"concurrent run, recorded outputs", false, false},
+		{"concurrent run, re-executed", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			live := duplicateIDNet()
+			journal, err := OpenJournal(t.TempDir(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer journal.Close()
+			if err := journal.WriteSnapshot(live.Snapshot()); err != nil {
+				t.Fatal(err)
+			}
+			live.AddObserver(journal)
+			if _, err := live.Run(ctx, RunOptions{Sequential: tt.sequential}); err != nil {
+				t.Fatal(err)
+			}
+			if err := journal.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			rebuilt := duplicateIDNet()
+			if _, err := journal.Replay(ctx, rebuilt, ReplayOptions{Execute: tt.execute}); err != nil {
+				t.Fatal(err)
+			}
+			want := describe(live.Snapshot(), tt.sequential)
+			got := describe(rebuilt.Snapshot(), tt.sequential)
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("replayed marking %v, live marking %v", got, want)
+			}
+			if data := rebuilt.Snapshot()["trash"][0].Data; data != "b-processed" {
+				t.Errorf("replay dropped %v, want b-processed", data)
+			}
+		})
+	}
+}
+
+func TestJournalHistoryBySerial(t *testing.T) {
+	net := duplicateIDNet()
+	journal, err := OpenJournal(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	net.AddObserver(journal)
+	if _, err := net.Run(context.Background(), RunOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dropped := net.Snapshot()["trash"][0]
+	history, err := journal.History(dropped.Serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fired []string
+	for _, e := range history {
+		fired = append(fired, e.Transition)
+	}
+	// The processed "b" was produced by one firing of process and moved by drop.
+	if fmt.Sprint(fired) != "[process drop]" {
+		t.Errorf("history of token #%d = %v, want [process drop]", dropped.Serial, fired)
+	}
+}
+
+func TestJournalRecordsExternalChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		execute bool
+		rewind  bool // replay into the live net, which the journal observes
+	}{
+		{"recorded outputs", false, false},
+		{"re-executed", true, false},
+		{"rewinding the live net", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			live := duplicateIDNet()
+			journal, err := OpenJournal(t.TempDir(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer journal.Close()
+			if err := journal.WriteSnapshot(live.Snapshot()); err != nil {
+				t.Fatal(err)
+			}
+			live.AddObserver(journal)
+
+			// After the snapshot: inject "d" and take "a" out by hand.
+			injected := &Token{ID: "doc", Data: "d"}
+			if err := live.Places["in"].AddTokens(injected); err != nil {
+				t.Fatal(err)
+			}
+			taken, err := live.Places["in"].RemoveTokens(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := live.Run(ctx, RunOptions{Sequential: true}); err != nil {
+				t.Fatal(err)
+			}
+			if err := journal.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, h := range []struct {
+				tok  *Token
+				want string
+			}{
+				{injected, "[external process]"},
+				{taken[0], "[external]"},
+			} {
+				history, err := journal.History(h.tok.Serial)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var changes []string
+				for _, e := range history {
+					changes = append(changes, e.name())
+				}
+				if got := fmt.Sprint(changes); got != h.want {
+					t.Errorf("history of %v = %s, want %s", h.tok.Data, got, h.want)
+				}
+			}
+
+			rebuilt := duplicateIDNet()
+			if tt.rewind {
+				rebuilt = live
+			}
+			want := describe(live.Snapshot(), true)
+			if _, err := journal.Replay(ctx, rebuilt, ReplayOptions{Execute: tt.execute}); err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(rebuilt.Snapshot(), true); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("replayed marking %v, live marking %v", got, want)
+			}
+		})
+	}
+}
+
+func TestJournalReplayFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot bool
+		change   func(net *PetriNet) // applied to the rebuilt net
+		wantErr  error
+	}{
+		{"no snapshot", false, func(net *PetriNet) {}, ErrNoSnapshot},
+		{"action fails", true, func(net *PetriNet) {
+			net.Transitions["process"].Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+				return nil, errors.New("model unavailable")
+			}
+		}, ErrReplayDiverged},
+		{"more outputs than recorded", true, func(net *PetriNet) {
+			net.Transitions["process"].OutputArcs[0].Weight = 2
+		}, ErrReplayDiverged},
+		{"transition removed", true, func(net *PetriNet) {
+			delete(net.Transitions, "drop")
+		}, ErrReplayDiverged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live := duplicateIDNet()
+			journal, err := OpenJournal(t.TempDir(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer journal.Close()
+			if tt.snapshot {
+				if err := journal.WriteSnapshot(live.Snapshot()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			live.AddObserver(journal)
+			if _, err := live.Run(context.Background(), RunOptions{Sequential: true}); err != nil {
+				t.Fatal(err)
+			}
+
+			rebuilt := duplicateIDNet()
+			tt.change(rebuilt)
+			_, err = journal.Replay(context.Background(), rebuilt, ReplayOptions{Execute: true})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Replay() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJournalCompact(t *testing.T) {
+	ctx := context.Background()
+	live := duplicateIDNet()
+	journal, err := OpenJournal(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	if err := journal.WriteSnapshot(live.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	live.AddObserver(journal)
+	if _, err := live.Run(ctx, RunOptions{MaxFirings: 2, Sequential: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := journal.Entries(); err != nil || len(entries) != 0 {
+		t.Fatalf("Entries() after Compact = %d entries, %v; want none", len(entries), err)
+	}
+	// Firings after the compaction land in a new segment on top of it.
+	if _, err := live.Run(ctx, RunOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilt := duplicateIDNet()
+	if _, err := journal.Replay(ctx, rebuilt, ReplayOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want, got := describe(live.Snapshot(), false), describe(rebuilt.Snapshot(), false)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("replayed marking %v, live marking %v", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package petrinet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrFiringInFlight is returned by Restore while a firing has reserved tokens
+// that it has not committed or rolled back yet.
+var ErrFiringInFlight = errors.New("firing in flight")
+
+// Marking is the state of a net: the tokens of every place, keyed by place ID
+// and listed in queue order. Tokens are copies; their Data is shared.
+type Marking map[string][]*Token
+
+// Counts returns the number of tokens per place ID.
+func (m Marking) Counts() map[string]int {
+	counts := make(map[string]int, len(m))
+	for id, tokens := range m {
+		counts[id] = len(tokens)
+	}
+	return counts
+}
+
+// Snapshot returns the current marking. It locks every place at once, so it is
+// consistent with concurrent firings: a firing that has reserved its inputs but
+// not committed yet appears as not started, its tokens back in their places.
+func (pn *PetriNet) Snapshot() Marking {
+	pn.mu.RLock()
+	defer pn.mu.RUnlock()
+
+	places := pn.placesInLockOrder()
+	lockPlaces(places)
+	defer unlockPlaces(places)
+
+	marking := make(Marking, len(places))
+	for _, place := range places {
+		tokens := place.Tokens
+		if len(place.held) > 0 {
+			tokens = append([]*Token(nil), tokens...)
+			if r, ok := place.Ordering.(restorer); ok {
+				tokens = r.Restore(tokens, place.held...)
+			} else {
+				tokens = append(append([]*Token(nil), place.held...), tokens...)
+			}
+		}
+		marking[place.ID] = copyTokens(tokens)
+	}
+	return marking
+}
+
+// Restore replaces the tokens of every place with those of m; places missing
+// from m are emptied. It fails without changing anything if m names an unknown
+// place or exceeds a capacity, and with ErrFiringInFlight while a firing is in
+// flight, so that no firing can commit into a restored marking. A place the
+// net shares through a FusionSet is restored for every net sharing it.
+func (pn *PetriNet) Restore(m Marking) error {
+	pn.mu.RLock()
+	for id := range m {
+		if _, ok := pn.Places[id]; !ok {
+			pn.mu.RUnlock()
+			return fmt.Errorf("marking references unknown place %s", id)
+		}
+	}
+	places := pn.placesInLockOrder()
+	pn.mu.RUnlock()
+
+	lockPlaces(places)
+	for _, place := range places {
+		if place.pending > 0 || place.readers > 0 {
+			unlockPlaces(places)
+			return fmt.Errorf("cannot restore %s: %w", pn.Name, ErrFiringInFlight)
+		}
+		if place.Capacity >= 0 && len(m[place.ID]) > place.Capacity {
+			unlockPlaces(places)
+			return fmt.Errorf("place %s at capacity (%d)", place.Name, place.Capacity)
+		}
+	}
+	removed := make(map[*Place][]*Token, len(places))
+	added := make(map[*Place][]*Token, len(places))
+	for _, place := range places {
+		removed[place] = place.Tokens
+		added[place] = copyTokens(m[place.ID])
+		stampSerials(added[place])
+		place.setTokens(added[place])
+	}
+	unlockPlaces(places)
+
+	for _, place := range places {
+		place.notify(added[place], removed[place], true)
+	}
+	return nil
+}
+
+// placesInLockOrder returns every place of the net sorted like the places of a
+// firing, so that locking them all cannot deadlock with firings. Callers must
+// hold pn.mu.
+func (pn *PetriNet) placesInLockOrder() []*Place {
+	places := make([]*Place, 0, len(pn.Places))
+	for _, place := range pn.Places {
+		places = append(places, place)
+	}
+	sort.Slice(places, func(i, j int) bool { return places[i].lockSeq() < places[j].lockSeq() })
+	return places
+}
+
+func copyTokens(tokens []*Token) []*Token {
+	copied := make([]*Token, len(tokens))
+	for i, tok := range tokens {
+		c := *tok
+		copied[i] = &c
+	}
+	return copied
+}
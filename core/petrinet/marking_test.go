@@ -0,0 +1,103 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	net := pipeNet("roundtrip", 3)
+	before := net.Snapshot()
+	before["in"][0].ID = "changed"
+	if net.Places["in"].Tokens[0].ID == "changed" {
+		t.Fatal("snapshot shares tokens with the net")
+	}
+	before["in"][0].ID = "roundtrip-0"
+
+	if _, err := net.Run(context.Background(), RunOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.Restore(before); err != nil {
+		t.Fatal(err)
+	}
+	if got := tokenIDs(net.Places["in"].Tokens); got != "roundtrip-0 roundtrip-1 roundtrip-2" {
+		t.Errorf("in = %s after Restore", got)
+	}
+	if n := net.Places["out"].TokenCount(); n != 0 {
+		t.Errorf("out holds %d tokens after Restore, want 0", n)
+	}
+}
+
+func TestRestoreRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		marking Marking
+		wantErr string
+	}{
+		{"unknown place", Marking{"nowhere": {{ID: "x"}}}, "unknown place nowhere"},
+		{"over capacity", Marking{"out": {{ID: "a"}, {ID: "b"}, {ID: "c"}}}, "at capacity (2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := pipeNet("reject", 1)
+			net.Places["out"].Capacity = 2
+			err := net.Restore(tt.marking)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Restore() error = %v, want %q", err, tt.wantErr)
+			}
+			if n := net.Places["in"].TokenCount(); n != 1 {
+				t.Errorf("failed Restore changed in to %d tokens", n)
+			}
+		})
+	}
+}
+
+func TestSnapshotDuringFiring(t *testing.T) {
+	net := pipeNet("inflight", 1)
+	started, release := make(chan struct{}), make(chan struct{})
+	net.Transitions["move"].Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+		close(started)
+		<-release
+		return tokens, nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- net.Transitions["move"].Fire(context.Background()) }()
+	<-started
+
+	// The firing has not committed, so its token still counts as in place.
+	if counts := net.Snapshot().Counts(); counts["in"] != 1 || counts["out"] != 0 {
+		t.Errorf("snapshot during firing = %v, want in:1 out:0", counts)
+	}
+	if err := net.Restore(Marking{}); !errors.Is(err, ErrFiringInFlight) {
+		t.Errorf("Restore() during firing error = %v, want ErrFiringInFlight", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSnapshotsOfRunningNet takes snapshots while a net fires concurrently;
+// run it with -race.
+func TestSnapshotsOfRunningNet(t *testing.T) {
+	const jobs = 40
+	net := conflictNet(jobs)
+	done := make(chan struct{})
+	go func() {
+		net.Run(context.Background(), RunOptions{MaxConcurrency: 8})
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		counts := net.Snapshot().Counts()
+		if total := counts["jobs"] + counts["done"]; total != jobs {
+			t.Fatalf("snapshot %v holds %d tokens, want %d", counts, total, jobs)
+		}
+	}
+}
@@ -8,12 +8,29 @@ import (
 	"time"
 )
 
+// ErrNetRunning is returned by changes to the structure of a net that cannot
+// be made while one of its runs is in progress.
+var ErrNetRunning = errors.New("net is running")
+
 // PetriNet orchestrates places and transitions
 type PetriNet struct {
 	Name        string
 	Places      map[string]*Place
 	Transitions map[string]*Transition
+	Clock       Clock          // Time source for timed transitions; nil = RealClock
+	Store       Store          // Checkpoint target: the marking is saved after every committed firing and external change; nil = none
+	Conflict    ConflictPolicy // Which enabled transition is tried first; nil = map order, or seeded draw in deterministic runs
 	mu          sync.RWMutex
+	observers   []Observer
+	running     int // runs in progress, guarded by mu
+
+	// Scheduler state: places changed since the scheduler last looked, the
+	// first failed checkpoint of an external change not reported by a run
+	// yet, and a wake-up signal for a scheduler waiting on changes.
+	changedMu     sync.Mutex
+	changed       map[*Place]struct{}
+	checkpointErr error
+	wake          chan struct{}
 }
 
 // NewPetriNet creates a new Petri net
@@ -22,6 +39,8 @@ func NewPetriNet(name string) *PetriNet {
 		Name:        name,
 		Places:      make(map[string]*Place),
 		Transitions: make(map[string]*Transition),
+		changed:     make(map[*Place]struct{}),
+		wake:        make(chan struct{}, 1),
 	}
 }
 
@@ -30,6 +49,7 @@ func (pn *PetriNet) AddPlace(place *Place) {
 	pn.mu.Lock()
 	defer pn.mu.Unlock()
 	pn.Places[place.ID] = place
+	place.watch(pn.placeChanged)
 }
 
 // AddTransition adds a transition to the net
@@ -37,76 +57,53 @@ func (pn *PetriNet) AddTransition(transition *Transition) {
 	pn.mu.Lock()
 	defer pn.mu.Unlock()
 	pn.Transitions[transition.ID] = transition
+	transition.net = pn
 }
 
-// Run executes the Petri net until no transitions can fire
-func (pn *PetriNet) Run(ctx context.Context) error {
-	fmt.Printf("🚀 Starting Petri Net: %s\n", pn.Name)
-
-	iterations := 0
-	maxIterations := 1000 // Prevent infinite loops
-
-	for iterations < maxIterations {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// Find all transitions that can fire
-		var fireable []*Transition
-		pn.mu.RLock()
-		for _, t := range pn.Transitions {
-			if t.CanFire() {
-				fireable = append(fireable, t)
-			}
-		}
-		pn.mu.RUnlock()
-
-		if len(fireable) == 0 {
-			fmt.Printf("✅ No more transitions can fire. Completed in %d iterations.\n", iterations)
-			break
-		}
-
-		// Fire all enabled transitions (maximal concurrency)
-		var wg sync.WaitGroup
-		errorsChan := make(chan error, len(fireable))
-
-		for _, t := range fireable {
-			wg.Add(1)
-			go func(transition *Transition) {
-				defer wg.Done()
-				if err := transition.Fire(ctx); err != nil {
-					if errors.Is(err, ErrNotReady) {
-						return
-					}
-					errorsChan <- fmt.Errorf("%s: %w", transition.Name, err)
-					return
-				}
-				fmt.Printf("  🔥 Fired: %s\n", transition.Name)
-			}(t)
-		}
-
-		wg.Wait()
-		close(errorsChan)
-
-		// Check for errors
-		for err := range errorsChan {
-			if err != nil {
-				return err
-			}
-		}
-
-		iterations++
-		time.Sleep(10 * time.Millisecond) // Small delay for readability
+// Run executes the Petri net until no transitions can fire and no firing is in
+// flight, or until one of the limits in opts is reached. The returned report is
+// never nil. The error is non-nil when an action failed (StopFailed) or the
+// context was cancelled (StopCancelled).
+func (pn *PetriNet) Run(ctx context.Context, opts RunOptions) (*RunReport, error) {
+	report := newRunReport(pn.Name)
+	pn.mu.Lock()
+	pn.running++
+	pn.mu.Unlock()
+	defer func() {
+		pn.mu.Lock()
+		pn.running--
+		pn.mu.Unlock()
+	}()
+	pn.eachObserver(func(o Observer) { o.OnRunStart(pn, opts) })
+
+	runCtx := ctx
+	if opts.Budget > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Budget)
+		defer cancel()
 	}
 
-	if iterations >= maxIterations {
-		return fmt.Errorf("reached max iterations (%d), possible infinite loop", maxIterations)
+	pn.schedule(runCtx, opts, report)
+
+	// Cancellation of the budget context is a budget stop, not a caller cancellation.
+	if report.StopReason == StopCancelled && ctx.Err() == nil {
+		report.StopReason = StopBudget
 	}
 
-	pn.PrintState()
-	return nil
+	report.FinalMarking = pn.tokenCounts()
+	report.Elapsed = time.Since(report.StartedAt)
+	if report.StopReason == StopQuiescent && opts.IsFinal != nil && !opts.IsFinal(report.FinalMarking) {
+		report.StopReason = StopDeadlock
+	}
+	pn.eachObserver(func(o Observer) { o.OnRunStop(pn, report) })
+
+	switch report.StopReason {
+	case StopFailed:
+		return report, report.Errors[0]
+	case StopCancelled:
+		return report, ctx.Err()
+	}
+	return report, nil
 }
 
 // PrintState shows current state of all places
@@ -120,38 +117,8 @@ func (pn *PetriNet) PrintState() {
 	}
 }
 
-// RunContinuous runs the net continuously, firing transitions as they become enabled
-func (pn *PetriNet) RunContinuous(ctx context.Context, pollInterval time.Duration) error {
-	fmt.Printf("🔄 Starting Continuous Petri Net: %s\n", pn.Name)
-
-	for {
-		select {
-		case <-ctx.Done():
-			fmt.Println("⏹️  Stopped")
-			return ctx.Err()
-		default:
-		}
-
-		// Find and fire one transition
-		fired := false
-		pn.mu.RLock()
-		for _, t := range pn.Transitions {
-			if t.CanFire() {
-				pn.mu.RUnlock()
-				if err := t.Fire(ctx); err == nil {
-					fmt.Printf("  🔥 Fired: %s\n", t.Name)
-					fired = true
-				} else if !errors.Is(err, ErrNotReady) {
-					return err
-				}
-				pn.mu.RLock()
-				break
-			}
-		}
-		pn.mu.RUnlock()
-
-		if !fired {
-			time.Sleep(pollInterval)
-		}
-	}
+// RunContinuous runs the net until the context is cancelled, firing transitions
+// as soon as token changes enable them (including tokens added from outside).
+func (pn *PetriNet) RunContinuous(ctx context.Context) (*RunReport, error) {
+	return pn.Run(ctx, RunOptions{Continuous: true})
 }
@@ -0,0 +1,112 @@
+package petrinet
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Observer receives engine lifecycle events. Callbacks are invoked synchronously
+// and possibly from several goroutines at once, so implementations must be
+// safe for concurrent use and should return quickly.
+type Observer interface {
+	OnRunStart(net *PetriNet, opts RunOptions)
+	OnRunStop(net *PetriNet, report *RunReport)
+	OnTransitionEnabled(t *Transition)
+	OnFiringStarted(ev FiringEvent)
+	OnFiringCompleted(ev FiringEvent)
+	OnFiringFailed(ev FiringEvent)
+	OnGuardRejected(t *Transition, tokens []*Token)
+	OnTokensAdded(place *Place, tokens []*Token)
+	OnTokensRemoved(place *Place, tokens []*Token)
+	// OnExternalChange reports a change made outside any firing (AddTokens,
+	// RemoveTokens, Restore), after OnTokensAdded and OnTokensRemoved.
+	OnExternalChange(place *Place, added, removed []*Token)
+}
+
+// FiringEvent describes one firing of a transition. Produced and Duration are
+// set once the firing has completed; Err is set when its action failed.
+type FiringEvent struct {
+	Transition *Transition
+	Consumed   map[string][]*Token // Input tokens keyed by place ID
+	Read       map[string][]*Token // Tokens read through read arcs, keyed by place ID
+	Reset      map[string][]*Token // Tokens dropped by reset arcs, keyed by place ID
+	Produced   map[string][]*Token // Output tokens keyed by place ID
+	Duration   time.Duration
+	Err        error
+}
+
+// BaseObserver implements Observer with no-op callbacks. Embed it to implement
+// only the callbacks you need.
+type BaseObserver struct{}
+
+func (BaseObserver) OnRunStart(*PetriNet, RunOptions)            {}
+func (BaseObserver) OnRunStop(*PetriNet, *RunReport)             {}
+func (BaseObserver) OnTransitionEnabled(*Transition)             {}
+func (BaseObserver) OnFiringStarted(FiringEvent)                 {}
+func (BaseObserver) OnFiringCompleted(FiringEvent)               {}
+func (BaseObserver) OnFiringFailed(FiringEvent)                  {}
+func (BaseObserver) OnGuardRejected(*Transition, []*Token)       {}
+func (BaseObserver) OnTokensAdded(*Place, []*Token)              {}
+func (BaseObserver) OnTokensRemoved(*Place, []*Token)            {}
+func (BaseObserver) OnExternalChange(*Place, []*Token, []*Token) {}
+
+// AddObserver attaches an observer to the net. Observers are called in the
+// order they were added.
+func (pn *PetriNet) AddObserver(o Observer) {
+	pn.mu.Lock()
+	defer pn.mu.Unlock()
+	pn.observers = append(pn.observers, o)
+}
+
+// eachObserver calls fn for every attached observer without holding pn.mu.
+func (pn *PetriNet) eachObserver(fn func(Observer)) {
+	pn.mu.RLock()
+	observers := pn.observers
+	pn.mu.RUnlock()
+
+	for _, o := range observers {
+		fn(o)
+	}
+}
+
+// ConsoleObserver prints run progress in a human-readable form.
+type ConsoleObserver struct {
+	BaseObserver
+	w io.Writer
+}
+
+// NewConsoleObserver creates an observer that prints run progress to w.
+func NewConsoleObserver(w io.Writer) *ConsoleObserver {
+	return &ConsoleObserver{w: w}
+}
+
+func (c *ConsoleObserver) OnRunStart(net *PetriNet, opts RunOptions) {
+	if opts.Continuous {
+		fmt.Fprintf(c.w, "🔄 Starting Continuous Petri Net: %s\n", net.Name)
+		return
+	}
+	fmt.Fprintf(c.w, "🚀 Starting Petri Net: %s\n", net.Name)
+}
+
+func (c *ConsoleObserver) OnRunStop(net *PetriNet, report *RunReport) {
+	switch report.StopReason {
+	case StopQuiescent:
+		fmt.Fprintf(c.w, "✅ No more transitions can fire. Completed after %d firings.\n", report.Firings)
+	case StopDeadlock:
+		fmt.Fprintf(c.w, "⛔ Deadlock after %d firings: no transition can fire and the marking is not final.\n", report.Firings)
+	default:
+		fmt.Fprintf(c.w, "⏹️  Stopped (%s) after %d firings\n", report.StopReason, report.Firings)
+	}
+}
+
+func (c *ConsoleObserver) OnFiringCompleted(ev FiringEvent) {
+	fmt.Fprintf(c.w, "  🔥 Fired: %s\n", ev.Transition.Name)
+	for placeID, tokens := range ev.Reset {
+		fmt.Fprintf(c.w, "  🧹 %s dropped %d tokens from %s\n", ev.Transition.Name, len(tokens), placeID)
+	}
+}
+
+func (c *ConsoleObserver) OnFiringFailed(ev FiringEvent) {
+	fmt.Fprintf(c.w, "  ❌ Failed: %s: %v\n", ev.Transition.Name, ev.Err)
+}
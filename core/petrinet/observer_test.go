@@ -0,0 +1,152 @@
+package petrinet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recorder is an observer that records the events it receives.
+type recorder struct {
+	BaseObserver
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+func (r *recorder) count(event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, e := range r.events {
+		if e == event {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *recorder) OnRunStart(*PetriNet, RunOptions)          { r.add("run-start") }
+func (r *recorder) OnRunStop(*PetriNet, *RunReport)           { r.add("run-stop") }
+func (r *recorder) OnTransitionEnabled(t *Transition)         { r.add("enabled " + t.ID) }
+func (r *recorder) OnFiringStarted(ev FiringEvent)            { r.add("started " + ev.Transition.ID) }
+func (r *recorder) OnFiringCompleted(ev FiringEvent)          { r.add("completed " + ev.Transition.ID) }
+func (r *recorder) OnFiringFailed(ev FiringEvent)             { r.add("failed " + ev.Transition.ID) }
+func (r *recorder) OnGuardRejected(t *Transition, _ []*Token) { r.add("rejected " + t.ID) }
+func (r *recorder) OnTokensAdded(p *Place, _ []*Token)        { r.add("added " + p.ID) }
+func (r *recorder) OnTokensRemoved(p *Place, _ []*Token)      { r.add("removed " + p.ID) }
+
+func TestObserverEvents(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name   string
+		action func(context.Context, []*Token) ([]*Token, error)
+		want   map[string]int
+	}{
+		{"commits", nil, map[string]int{
+			"run-start": 1, "run-stop": 1, "enabled move": 1,
+			"started move": 2, "completed move": 2, "failed move": 0,
+			"removed in": 2, "added out": 2,
+		}},
+		{"action fails", func(context.Context, []*Token) ([]*Token, error) { return nil, failed }, map[string]int{
+			"run-start": 1, "run-stop": 1,
+			"started move": 1, "completed move": 0, "failed move": 1,
+			"removed in": 1, "added in": 1, "added out": 0,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := pipeNet("observed", 2)
+			net.Transitions["move"].Action = tt.action
+			r := &recorder{}
+			net.AddObserver(r)
+			net.Run(context.Background(), RunOptions{Sequential: true})
+
+			for event, n := range tt.want {
+				if got := r.count(event); got != n {
+					t.Errorf("%d %q events, want %d", got, event, n)
+				}
+			}
+			if first, last := r.events[0], r.events[len(r.events)-1]; first != "run-start" || last != "run-stop" {
+				t.Errorf("events run from %q to %q, want run-start to run-stop", first, last)
+			}
+		})
+	}
+}
+
+func TestFireNotifiesObservers(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name    string
+		action  func(context.Context, []*Token) ([]*Token, error)
+		store   Store
+		wantErr error
+		want    map[string]int
+	}{
+		{"commits", nil, nil, nil, map[string]int{"started move": 1, "completed move": 1, "failed move": 0}},
+		{"action fails", func(context.Context, []*Token) ([]*Token, error) { return nil, failed }, nil, failed,
+			map[string]int{"started move": 1, "completed move": 0, "failed move": 1}},
+		{"checkpoint fails", nil, failingStore{}, errDiskFull, map[string]int{"started move": 1, "completed move": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := pipeNet("fired", 1)
+			net.Transitions["move"].Action = tt.action
+			net.Store = tt.store
+			r := &recorder{}
+			net.AddObserver(r)
+
+			err := net.Transitions["move"].Fire(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fire() error = %v, want %v", err, tt.wantErr)
+			}
+			for event, n := range tt.want {
+				if got := r.count(event); got != n {
+					t.Errorf("%d %q events, want %d", got, event, n)
+				}
+			}
+		})
+	}
+}
+
+// TestObserverConcurrentFirings counts events of concurrent firings; run it
+// with -race.
+func TestObserverConcurrentFirings(t *testing.T) {
+	net := conflictNet(50)
+	r := &recorder{}
+	net.AddObserver(r)
+	if _, err := net.Run(context.Background(), RunOptions{MaxConcurrency: 8}); err != nil {
+		t.Fatal(err)
+	}
+	started, completed := 0, 0
+	for _, id := range []string{"a", "b", "c"} {
+		started += r.count("started " + id)
+		completed += r.count("completed " + id)
+	}
+	if started != 50 || completed != 50 {
+		t.Errorf("%d firings started and %d completed, want 50", started, completed)
+	}
+	if n := r.count("added done"); n != 50 {
+		t.Errorf("%d token additions to done, want 50", n)
+	}
+}
+
+func TestConsoleObserver(t *testing.T) {
+	net := pipeNet("console", 1)
+	var buf bytes.Buffer
+	net.AddObserver(NewConsoleObserver(&buf))
+	net.Run(context.Background(), RunOptions{})
+	for _, want := range []string{"Starting Petri Net: console", "Fired: move", "Completed after 1 firings"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package petrinet
+
+// Ordering decides where new tokens are placed in a place's queue. The head of
+// Place.Tokens is always the next token offered to consuming transitions.
+type Ordering interface {
+	// Insert returns queue with tokens added in their discipline's position.
+	Insert(queue []*Token, tokens ...*Token) []*Token
+}
+
+// restorer is implemented by orderings that need a specific position for
+// tokens returned by a rolled-back firing. Other orderings get them back at the head.
+type restorer interface {
+	Restore(queue []*Token, tokens ...*Token) []*Token
+}
+
+var (
+	// FIFO consumes tokens in arrival order. It is the default for places without an Ordering.
+	FIFO Ordering = fifoOrder{}
+	// LIFO consumes the most recently added token first.
+	LIFO Ordering = lifoOrder{}
+	// ByPriority consumes tokens with the highest Token.Priority first, FIFO among equal priorities.
+	ByPriority Ordering = NewPriorityOrder(nil)
+)
+
+type fifoOrder struct{}
+
+func (fifoOrder) Insert(queue []*Token, tokens ...*Token) []*Token {
+	return append(queue, tokens...)
+}
+
+type lifoOrder struct{}
+
+func (lifoOrder) Insert(queue []*Token, tokens ...*Token) []*Token {
+	result := make([]*Token, 0, len(queue)+len(tokens))
+	for i := len(tokens) - 1; i >= 0; i-- {
+		result = append(result, tokens[i])
+	}
+	return append(result, queue...)
+}
+
+// PriorityOrder keeps the queue sorted so that tokens for which Less reports
+// true come first. Tokens that compare equal keep their arrival order.
+type PriorityOrder struct {
+	Less func(a, b *Token) bool
+}
+
+// NewPriorityOrder creates a priority ordering from a comparator reporting
+// whether a should be consumed before b. A nil comparator orders by
+// descending Token.Priority.
+func NewPriorityOrder(less func(a, b *Token) bool) *PriorityOrder {
+	if less == nil {
+		less = func(a, b *Token) bool { return a.Priority > b.Priority }
+	}
+	return &PriorityOrder{Less: less}
+}
+
+// Insert places each token after every token it does not precede.
+func (o *PriorityOrder) Insert(queue []*Token, tokens ...*Token) []*Token {
+	for _, tok := range tokens {
+		i := len(queue)
+		for i > 0 && o.Less(tok, queue[i-1]) {
+			i--
+		}
+		queue = insertAt(queue, i, tok)
+	}
+	return queue
+}
+
+// Restore places each token before the tokens it does not follow, so a token
+// returned after a failed firing is offered again ahead of equal-priority arrivals.
+func (o *PriorityOrder) Restore(queue []*Token, tokens ...*Token) []*Token {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		tok := tokens[i]
+		j := 0
+		for j < len(queue) && o.Less(queue[j], tok) {
+			j++
+		}
+		queue = insertAt(queue, j, tok)
+	}
+	return queue
+}
+
+func insertAt(queue []*Token, i int, tok *Token) []*Token {
+	queue = append(queue, nil)
+	copy(queue[i+1:], queue[i:])
+	queue[i] = tok
+	return queue
+}
+
+// insert adds tokens according to the place's ordering. Callers must hold p.mu.
+func (p *Place) insert(tokens []*Token) {
+	stampSerials(tokens)
+	p.timed += countTimed(tokens)
+	if p.Ordering == nil {
+		p.Tokens = FIFO.Insert(p.Tokens, tokens...)
+		return
+	}
+	p.Tokens = p.Ordering.Insert(p.Tokens, tokens...)
+}
+
+// restore returns tokens of a rolled-back firing to the queue. Callers must hold p.mu.
+func (p *Place) restore(tokens []*Token) {
+	p.timed += countTimed(tokens)
+	if r, ok := p.Ordering.(restorer); ok {
+		p.Tokens = r.Restore(p.Tokens, tokens...)
+		return
+	}
+	p.Tokens = append(append([]*Token(nil), tokens...), p.Tokens...)
+}
@@ -0,0 +1,64 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOrderings(t *testing.T) {
+	tests := []struct {
+		name       string
+		ordering   Ordering
+		priorities []int // Priority of tokens t0, t1, ... added one by one
+		want       string
+	}{
+		{"default is fifo", nil, []int{0, 0, 0}, "t0 t1 t2"},
+		{"fifo", FIFO, []int{0, 0, 0}, "t0 t1 t2"},
+		{"lifo", LIFO, []int{0, 0, 0}, "t2 t1 t0"},
+		{"priority", ByPriority, []int{1, 5, 3}, "t1 t2 t0"},
+		{"priority keeps arrival order among equals", ByPriority, []int{2, 1, 2, 1}, "t0 t2 t1 t3"},
+		{"custom comparator", NewPriorityOrder(func(a, b *Token) bool { return a.ID > b.ID }), []int{0, 0, 0}, "t2 t1 t0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPlace("queue", "queue", -1)
+			p.Ordering = tt.ordering
+			for i, prio := range tt.priorities {
+				p.AddTokens(&Token{ID: fmt.Sprintf("t%d", i), Priority: prio})
+			}
+			if got := tokenIDs(p.Tokens); got != tt.want {
+				t.Errorf("queue = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderingsRestoreFailedFirings(t *testing.T) {
+	// The token taken by a failed firing is offered again first.
+	failed := errors.New("failed")
+	for _, ordering := range []Ordering{FIFO, LIFO, ByPriority} {
+		net := pipeNet("restore", 0)
+		in := net.Places["in"]
+		in.Ordering = ordering
+		for i, prio := range []int{3, 3, 1} {
+			in.AddTokens(&Token{ID: fmt.Sprintf("t%d", i), Priority: prio})
+		}
+		before := tokenIDs(in.Tokens)
+		net.Transitions["move"].Action = func(context.Context, []*Token) ([]*Token, error) { return nil, failed }
+		net.Transitions["move"].Fire(context.Background())
+		if got := tokenIDs(in.Tokens); got != before {
+			t.Errorf("%T: queue after rollback = %s, want %s", ordering, got, before)
+		}
+	}
+}
+
+func tokenIDs(tokens []*Token) string {
+	ids := make([]string, len(tokens))
+	for i, tok := range tokens {
+		ids[i] = tok.ID
+	}
+	return strings.Join(ids, " ")
+}
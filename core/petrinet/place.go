@@ -3,12 +3,50 @@ package petrinet
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Token represents data flowing through the Petri net
 type Token struct {
-	ID   string
-	Data interface{}
+	ID       string
+	Data     interface{}
+	Priority int // Used by ByPriority places; higher is consumed first
+
+	// AvailableAt is the earliest time the token can be consumed or read
+	// (zero = immediately). Timed transitions set it on the tokens they produce.
+	AvailableAt time.Time
+
+	// Serial identifies the token unlike ID, which need not be unique: it is
+	// assigned when the token first enters a place and kept while the token
+	// moves between places. Snapshots, checkpoints and the journal keep it.
+	Serial uint64
+}
+
+// tokenSerial is the last token serial handed out.
+var tokenSerial atomic.Uint64
+
+// stampSerials assigns serials to tokens that have none and makes sure later
+// serials are larger than those of tokens restored from a checkpoint, which
+// may come from an earlier process.
+func stampSerials(tokens []*Token) {
+	for _, tok := range tokens {
+		if tok.Serial == 0 {
+			tok.Serial = tokenSerial.Add(1)
+			continue
+		}
+		for {
+			last := tokenSerial.Load()
+			if tok.Serial <= last || tokenSerial.CompareAndSwap(last, tok.Serial) {
+				break
+			}
+		}
+	}
+}
+
+// availableAt reports whether the token can be used at now.
+func (tok *Token) availableAt(now time.Time) bool {
+	return !tok.AvailableAt.After(now)
 }
 
 // Place represents a state that can hold tokens
@@ -16,8 +54,47 @@ type Place struct {
 	ID       string
 	Name     string
 	Tokens   []*Token
-	Capacity int // -1 = unlimited
+	Capacity int      // -1 = unlimited
+	Ordering Ordering // Queue discipline; nil = FIFO
 	mu       sync.Mutex
+
+	// pending counts slots claimed by in-flight firings: consumed tokens whose
+	// firing has not finished yet and reserved output capacity. They count
+	// against Capacity so that a firing can always commit or roll back.
+	pending int
+
+	// readers counts in-flight firings reading tokens of the place through read arcs.
+	readers int
+
+	// held are the tokens consumed or reset by in-flight firings. Snapshots put
+	// them back, so that a snapshot never shows a firing halfway. Each firing
+	// appends its tokens as one contiguous run.
+	held []*Token
+
+	// timed counts the tokens in Tokens that carry an AvailableAt. Places
+	// without any skip the availability scan.
+	timed int
+
+	// watchers are notified after every change to the tokens of the place.
+	watchers []placeWatcher
+
+	// seq is the position of the place in the global lock order. Places are
+	// locked by seq rather than by ID so that places of different nets sharing
+	// an ID, and places shared by several nets, are always locked in one order.
+	seq uint64
+}
+
+// placeSeq hands out lock order positions.
+var placeSeq atomic.Uint64
+
+// lockSeq returns the position of the place in the lock order, assigning one
+// to places that were not created with NewPlace.
+func (p *Place) lockSeq() uint64 {
+	if seq := atomic.LoadUint64(&p.seq); seq != 0 {
+		return seq
+	}
+	atomic.CompareAndSwapUint64(&p.seq, 0, placeSeq.Add(1))
+	return atomic.LoadUint64(&p.seq)
 }
 
 // NewPlace creates a new place
@@ -27,36 +104,138 @@ func NewPlace(id, name string, capacity int) *Place {
 		Name:     name,
 		Tokens:   make([]*Token, 0),
 		Capacity: capacity,
+		seq:      placeSeq.Add(1),
 	}
 }
 
 // AddTokens adds tokens to the place (thread-safe)
 func (p *Place) AddTokens(tokens ...*Token) error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	if p.Capacity >= 0 && len(p.Tokens)+len(tokens) > p.Capacity {
+	if p.Capacity >= 0 && len(p.Tokens)+p.pending+len(tokens) > p.Capacity {
+		p.mu.Unlock()
 		return fmt.Errorf("place %s at capacity (%d)", p.Name, p.Capacity)
 	}
+	p.insert(tokens)
+	p.mu.Unlock()
 
-	p.Tokens = append(p.Tokens, tokens...)
+	p.notify(tokens, nil, true)
 	return nil
 }
 
 // RemoveTokens removes N tokens from the place
 func (p *Place) RemoveTokens(count int) ([]*Token, error) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	if len(p.Tokens) < count {
+		p.mu.Unlock()
 		return nil, fmt.Errorf("not enough tokens in %s (have %d, need %d)", p.Name, len(p.Tokens), count)
 	}
-
 	removed := p.Tokens[:count]
 	p.Tokens = p.Tokens[count:]
+	p.timed -= countTimed(removed)
+	p.mu.Unlock()
+
+	p.notify(nil, removed, true)
 	return removed, nil
 }
 
+// AvailableCount returns the number of tokens that can be used at now.
+func (p *Place) AvailableCount(now time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.availableLocked(now)
+}
+
+func (p *Place) availableLocked(now time.Time) int {
+	if p.timed == 0 {
+		return len(p.Tokens)
+	}
+	count := 0
+	for _, tok := range p.Tokens {
+		if tok.availableAt(now) {
+			count++
+		}
+	}
+	return count
+}
+
+// markedCount returns the number of tokens in the place, including those
+// consumed or reset by in-flight firings, which a rollback would put back.
+// Inhibitor arcs test this count.
+func (p *Place) markedCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.markedLocked()
+}
+
+func (p *Place) markedLocked() int {
+	return len(p.Tokens) + len(p.held)
+}
+
+// nextAvailable returns the earliest availability time after now among the
+// place's tokens, or the zero time if no token is waiting to become available.
+func (p *Place) nextAvailable(now time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var next time.Time
+	if p.timed == 0 {
+		return next
+	}
+	for _, tok := range p.Tokens {
+		if tok.AvailableAt.After(now) && (next.IsZero() || tok.AvailableAt.Before(next)) {
+			next = tok.AvailableAt
+		}
+	}
+	return next
+}
+
+// setTokens replaces the queue. Callers must hold p.mu.
+func (p *Place) setTokens(tokens []*Token) {
+	p.Tokens = tokens
+	p.timed = countTimed(tokens)
+}
+
+// removeAt removes the tokens at the given ascending indices of the queue.
+// Callers must hold p.mu.
+func (p *Place) removeAt(indices []int) {
+	for _, i := range indices {
+		if !p.Tokens[i].AvailableAt.IsZero() {
+			p.timed--
+		}
+	}
+	p.Tokens = removeIndices(p.Tokens, indices)
+}
+
+// unhold removes the n held tokens of a finished firing, the first of which
+// is first. Firings mostly finish in the order they started, so the run is
+// usually near the head; the shorter side of the slice is moved to close the gap.
+// Callers must hold p.mu.
+func (p *Place) unhold(first *Token, n int) {
+	for i, tok := range p.held {
+		if tok != first {
+			continue
+		}
+		if i < len(p.held)-i-n {
+			copy(p.held[n:i+n], p.held[:i])
+			clear(p.held[:n])
+			p.held = p.held[n:]
+		} else {
+			p.held = append(p.held[:i], p.held[i+n:]...)
+		}
+		return
+	}
+}
+
+func countTimed(tokens []*Token) int {
+	n := 0
+	for _, tok := range tokens {
+		if !tok.AvailableAt.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
 // TokenCount returns current number of tokens
 func (p *Place) TokenCount() int {
 	p.mu.Lock()
@@ -72,5 +251,28 @@ func (p *Place) CanAccept(count int) bool {
 	if p.Capacity < 0 {
 		return true
 	}
-	return len(p.Tokens)+count <= p.Capacity
+	return len(p.Tokens)+p.pending+count <= p.Capacity
+}
+
+// placeWatcher is called after the tokens of a place change. added and removed
+// may both be empty when only reserved capacity changed. external is set for
+// changes made outside firings: AddTokens, RemoveTokens and Restore.
+type placeWatcher func(p *Place, added, removed []*Token, external bool)
+
+// watch registers a callback invoked after the tokens of the place change.
+func (p *Place) watch(fn placeWatcher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.watchers = append(p.watchers, fn)
+}
+
+// notify calls the registered watchers. It must be called without holding p.mu.
+func (p *Place) notify(added, removed []*Token, external bool) {
+	p.mu.Lock()
+	watchers := p.watchers
+	p.mu.Unlock()
+
+	for _, fn := range watchers {
+		fn(p, added, removed, external)
+	}
 }
@@ -0,0 +1,164 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrReplayDiverged is returned when a re-executed firing cannot take the
+// recorded tokens or produces different outputs than recorded.
+var ErrReplayDiverged = errors.New("replay diverged from journal")
+
+// ReplayOptions configures Journal.Replay.
+type ReplayOptions struct {
+	// Execute fires every recorded firing again on exactly the recorded input
+	// tokens, running its action, instead of inserting the recorded outputs.
+	Execute bool
+	// Until is the last entry to replay, 0 = all entries.
+	Until uint64
+}
+
+// Replay rebuilds in net the marking recorded by the journal: it restores the
+// latest snapshot and applies the committed entries after it in sequence
+// order, returning the sequence number of the last entry applied. No firing
+// of net may be in flight. Without opts.Execute the recorded outputs are
+// inserted as they are. With it each firing runs its action again, and Replay
+// stops with ErrReplayDiverged as soon as a firing cannot bind the recorded
+// tokens or produces a different number of tokens for some place; external
+// changes are made as recorded. The journal may observe net: the changes
+// Replay makes to net are then journaled as external changes.
+func (j *Journal) Replay(ctx context.Context, net *PetriNet, opts ReplayOptions) (uint64, error) {
+	if !opts.Execute {
+		j.mu.Lock()
+		m, seq, err := j.replay(opts.Until, net)
+		j.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+		return seq, net.Restore(m)
+	}
+
+	base, seq, entries, err := j.since(opts.Until)
+	if err != nil {
+		return 0, err
+	}
+	if err := net.Restore(base); err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		switch {
+		case e.External:
+			if err := net.reapply(e); err != nil {
+				return seq, err
+			}
+		case e.Committed():
+			if err := net.refire(ctx, e); err != nil {
+				return seq, err
+			}
+		}
+		seq = e.Seq
+	}
+	return seq, nil
+}
+
+// since returns the latest snapshot, its sequence number and the entries
+// after it, up to and including until (0 = all).
+func (j *Journal) since(until uint64) (Marking, uint64, []*JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	base, seq, err := j.base()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	_, segments, err := j.files()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	var entries []*JournalEntry
+	for _, s := range segments {
+		seg, err := j.readSegment(s.path)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		for _, e := range seg {
+			if until > 0 && e.Seq > until {
+				return base, seq, entries, nil
+			}
+			if e.Seq > seq {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return base, seq, entries, nil
+}
+
+// reapply makes the external change recorded by e.
+func (pn *PetriNet) reapply(e *JournalEntry) error {
+	m := pn.Snapshot()
+	if err := m.apply(e, pn); err != nil {
+		return err
+	}
+	return pn.Restore(m)
+}
+
+// refire fires the transition of e on the tokens it recorded.
+func (pn *PetriNet) refire(ctx context.Context, e *JournalEntry) error {
+	pn.mu.RLock()
+	t, ok := pn.Transitions[e.Transition]
+	pn.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("entry %d: unknown transition %s: %w", e.Seq, e.Transition, ErrReplayDiverged)
+	}
+
+	want := make(map[uint64]bool)
+	for _, perPlace := range []map[string][]uint64{e.Consumed, e.Read} {
+		for _, serials := range perPlace {
+			for _, serial := range serials {
+				want[serial] = true
+			}
+		}
+	}
+	recorded := func(tokens []*Token) bool {
+		if len(tokens) != len(want) {
+			return false
+		}
+		for _, tok := range tokens {
+			if !want[tok.Serial] {
+				return false
+			}
+		}
+		return true
+	}
+
+	f, err := t.reserveMatching(e.Time, recorded)
+	if err != nil {
+		return fmt.Errorf("entry %d: %s cannot take the recorded tokens (%v): %w", e.Seq, t.ID, err, ErrReplayDiverged)
+	}
+	outputTokens, err := f.execute(ctx)
+	if err != nil {
+		f.abort()
+		return fmt.Errorf("entry %d: action failed for %s: %v: %w", e.Seq, t.Name, err, ErrReplayDiverged)
+	}
+
+	// The outputs take the recorded serials, so that later entries find them.
+	produced := make(map[string][]*Token)
+	offset := 0
+	for _, arc := range t.OutputArcs {
+		produced[arc.Place.ID] = append(produced[arc.Place.ID], outputTokens[offset:offset+arc.Weight]...)
+		offset += arc.Weight
+	}
+	for place, tokens := range produced {
+		if len(tokens) != len(e.Produced[place]) {
+			f.abort()
+			return fmt.Errorf("entry %d: %s produced %d tokens in %s, journal has %d: %w",
+				e.Seq, t.ID, len(tokens), place, len(e.Produced[place]), ErrReplayDiverged)
+		}
+		for i, tok := range tokens {
+			tok.Serial = e.Produced[place][i].Serial
+		}
+	}
+	f.commit(outputTokens)
+	return nil
+}
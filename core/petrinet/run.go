@@ -0,0 +1,148 @@
+package petrinet
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// StopReason explains why a run ended.
+type StopReason string
+
+const (
+	// StopQuiescent means no transition could fire and no firing was in flight.
+	StopQuiescent StopReason = "quiescent"
+	// StopBudget means MaxFirings or the wall-clock Budget was exhausted.
+	StopBudget StopReason = "budget"
+	// StopCancelled means the caller's context was cancelled.
+	StopCancelled StopReason = "cancelled"
+	// StopDeadlock means the net became quiescent in a marking that RunOptions.IsFinal rejects.
+	StopDeadlock StopReason = "deadlock"
+	// StopFailed means a transition action returned an error or a checkpoint could not be saved.
+	StopFailed StopReason = "failed"
+)
+
+// RunOptions configures a run of the net. The zero value runs until quiescence
+// with no limits.
+type RunOptions struct {
+	MaxFirings     int           // Maximum number of firings to start, 0 = unlimited
+	Budget         time.Duration // Wall-clock budget for the run, 0 = unlimited
+	MaxConcurrency int           // Maximum number of in-flight firings, 0 = unlimited
+
+	// Continuous keeps the run waiting for tokens added from outside after the
+	// net becomes quiescent; it then ends only on cancellation, budget or failure.
+	Continuous bool
+
+	// IsFinal classifies a quiescent marking (token count per place ID). When set
+	// and it returns false, the run stops with StopDeadlock instead of StopQuiescent.
+	IsFinal func(marking map[string]int) bool
+
+	// Deterministic replaces the map order of the net's transitions: enabled
+	// transitions are sorted by ID and the next one to fire is drawn with a
+	// random source seeded with Seed (or chosen by the net's ConflictPolicy,
+	// which gets that source), so that the same seed resolves conflicts the
+	// same way. It implies Sequential, since concurrent actions would finish
+	// in varying order. With a ManualClock for timed nets, the firing
+	// sequence, recorded in RunReport.Sequence, is reproducible as long as
+	// the actions are.
+	Deterministic bool
+	Seed          int64
+
+	// Sequential starts a firing only after the previous one has finished.
+	Sequential bool
+}
+
+// TransitionStats aggregates the firings of one transition during a run.
+type TransitionStats struct {
+	Firings       int           // Committed firings
+	Failures      int           // Firings whose action failed and were rolled back
+	TotalDuration time.Duration // Total time spent in the action
+	MaxDuration   time.Duration // Longest single action
+}
+
+// RunReport describes the outcome of a run.
+type RunReport struct {
+	Net          string
+	StopReason   StopReason
+	Firings      int                         // Committed firings across all transitions
+	Transitions  map[string]*TransitionStats // Keyed by transition ID
+	Errors       []error
+	FinalMarking map[string]int // Token count per place ID when the run ended
+	Sequence     []string       // Transition IDs of committed firings in order; Deterministic runs only
+	StartedAt    time.Time
+	Elapsed      time.Duration
+}
+
+func newRunReport(name string) *RunReport {
+	return &RunReport{
+		Net:         name,
+		Transitions: make(map[string]*TransitionStats),
+		StartedAt:   time.Now(),
+	}
+}
+
+// stats returns the statistics entry of a transition, creating it on first use.
+func (r *RunReport) stats(t *Transition) *TransitionStats {
+	s, ok := r.Transitions[t.ID]
+	if !ok {
+		s = &TransitionStats{}
+		r.Transitions[t.ID] = s
+	}
+	return s
+}
+
+func (r *RunReport) record(ev FiringEvent) {
+	s := r.stats(ev.Transition)
+	s.TotalDuration += ev.Duration
+	if ev.Duration > s.MaxDuration {
+		s.MaxDuration = ev.Duration
+	}
+	if ev.Err != nil {
+		s.Failures++
+		r.Errors = append(r.Errors, ev.Err)
+		return
+	}
+	s.Firings++
+	r.Firings++
+}
+
+// PrintSummary writes a human-readable summary of the report to w.
+func (r *RunReport) PrintSummary(w io.Writer) {
+	fmt.Fprintf(w, "Run of %s: %s after %d firings in %v\n", r.Net, r.StopReason, r.Firings, r.Elapsed)
+
+	ids := make([]string, 0, len(r.Transitions))
+	for id := range r.Transitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		s := r.Transitions[id]
+		fmt.Fprintf(w, "  %s: %d fired, %d failed, total %v, max %v\n", id, s.Firings, s.Failures, s.TotalDuration, s.MaxDuration)
+	}
+
+	places := make([]string, 0, len(r.FinalMarking))
+	for id := range r.FinalMarking {
+		places = append(places, id)
+	}
+	sort.Strings(places)
+	for _, id := range places {
+		fmt.Fprintf(w, "  [%s]: %d tokens\n", id, r.FinalMarking[id])
+	}
+
+	for _, err := range r.Errors {
+		fmt.Fprintf(w, "  error: %v\n", err)
+	}
+}
+
+// tokenCounts returns the current token count per place ID.
+func (pn *PetriNet) tokenCounts() map[string]int {
+	pn.mu.RLock()
+	defer pn.mu.RUnlock()
+
+	counts := make(map[string]int, len(pn.Places))
+	for id, place := range pn.Places {
+		counts[id] = place.TokenCount()
+	}
+	return counts
+}
@@ -0,0 +1,180 @@
+package petrinet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// conflictNet builds a net whose transitions a, b and c compete for jobs
+// tokens and whose actions take a random time, so that concurrent firings
+// finish in varying order.
+func conflictNet(jobs int) *PetriNet {
+	net := NewPetriNet("conflict")
+	in := NewPlace("jobs", "jobs", -1)
+	out := NewPlace("done", "done", -1)
+	net.AddPlace(in)
+	net.AddPlace(out)
+	for i := 0; i < jobs; i++ {
+		in.AddTokens(&Token{ID: fmt.Sprintf("job-%d", i)})
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		t := NewTransition(id, id)
+		t.AddInputArc(in, 1)
+		t.AddOutputArc(out, 1)
+		t.Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+			time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+			return tokens, nil
+		}
+		net.AddTransition(t)
+	}
+	return net
+}
+
+func TestDeterministicRunIsReproducible(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   RunOptions
+		policy func() ConflictPolicy
+	}{
+		{"seed 1", RunOptions{Deterministic: true, Seed: 1}, nil},
+		{"seed 42", RunOptions{Deterministic: true, Seed: 42}, nil},
+		{"seed 42, concurrency allowed", RunOptions{Deterministic: true, Seed: 42, MaxConcurrency: 8}, nil},
+		{"seed 7, least recently fired", RunOptions{Deterministic: true, Seed: 7}, func() ConflictPolicy { return NewLeastRecentlyFired() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var first string
+			for run := 0; run < 10; run++ {
+				net := conflictNet(15)
+				if tt.policy != nil {
+					net.Conflict = tt.policy()
+				}
+				report, err := net.Run(context.Background(), tt.opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(report.Sequence) != 15 {
+					t.Fatalf("sequence has %d firings, want 15", len(report.Sequence))
+				}
+				seq := fmt.Sprint(report.Sequence)
+				if run == 0 {
+					first = seq
+				} else if seq != first {
+					t.Fatalf("run %d fired %s, run 0 fired %s", run, seq, first)
+				}
+			}
+		})
+	}
+}
+
+func TestDeterministicSeedsDiffer(t *testing.T) {
+	seen := make(map[string]bool)
+	for seed := int64(1); seed <= 5; seed++ {
+		report, err := conflictNet(15).Run(context.Background(), RunOptions{Deterministic: true, Seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[fmt.Sprint(report.Sequence)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("5 seeds gave %d distinct sequences, want the seed to matter", len(seen))
+	}
+}
+
+func TestRunStopReasons(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name    string
+		prepare func(net *PetriNet)
+		opts    RunOptions
+		want    StopReason
+		wantErr error
+	}{
+		{"quiescent", func(*PetriNet) {}, RunOptions{}, StopQuiescent, nil},
+		{"max firings", func(*PetriNet) {}, RunOptions{MaxFirings: 2}, StopBudget, nil},
+		{"deadlock", func(*PetriNet) {}, RunOptions{IsFinal: func(m map[string]int) bool { return m["in"] > 0 }}, StopDeadlock, nil},
+		{"action fails", func(net *PetriNet) {
+			net.Transitions["move"].Action = func(context.Context, []*Token) ([]*Token, error) { return nil, failed }
+		}, RunOptions{}, StopFailed, failed},
+		{"budget", func(net *PetriNet) {
+			net.Transitions["move"].Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+		}, RunOptions{Budget: 10 * time.Millisecond}, StopBudget, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := pipeNet("stop", 5)
+			tt.prepare(net)
+			report, err := net.Run(context.Background(), tt.opts)
+			if report.StopReason != tt.want {
+				t.Errorf("StopReason = %s, want %s", report.StopReason, tt.want)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := report.FinalMarking["in"] + report.FinalMarking["out"]; got != 5 {
+				t.Errorf("final marking %v holds %d tokens, want 5", report.FinalMarking, got)
+			}
+		})
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	failed := errors.New("failed")
+	net := pipeNet("report", 3)
+	calls := 0
+	net.Transitions["move"].Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+		if calls++; calls == 3 {
+			return nil, failed
+		}
+		return tokens, nil
+	}
+	report, err := net.Run(context.Background(), RunOptions{Sequential: true})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Run() error = %v, want %v", err, failed)
+	}
+	stats := report.Transitions["move"]
+	if report.Firings != 2 || stats == nil || stats.Firings != 2 || stats.Failures != 1 {
+		t.Errorf("report counts %d firings and %+v, want 2 firings and 1 failure", report.Firings, stats)
+	}
+	if report.FinalMarking["in"] != 1 || report.FinalMarking["out"] != 2 {
+		t.Errorf("final marking = %v, want in:1 out:2", report.FinalMarking)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("report has %d errors, want 1", len(report.Errors))
+	}
+
+	var buf bytes.Buffer
+	report.PrintSummary(&buf)
+	for _, want := range []string{"Run of report: failed after 2 firings", "move: 2 fired, 1 failed", "[out]: 2 tokens", "error: action failed for move: failed"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("summary lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+// BenchmarkRunDrain moves every token of one place to another; the time per
+// token should not grow with the size of the queue.
+func BenchmarkRunDrain(b *testing.B) {
+	for _, tokens := range []int{1000, 10000, 40000} {
+		b.Run(fmt.Sprintf("tokens=%d", tokens), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				net := pipeNet("drain", tokens)
+				b.StartTimer()
+				if _, err := net.Run(context.Background(), RunOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*tokens), "ns/token")
+		})
+	}
+}
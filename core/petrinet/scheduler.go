@@ -0,0 +1,390 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// placeChanged records a token change, reports it to observers and wakes the
+// scheduler. External changes are checkpointed like committed firings, so that
+// tokens added while nothing fires survive a restart; a failed checkpoint
+// stops the current or next run.
+func (pn *PetriNet) placeChanged(p *Place, added, removed []*Token, external bool) {
+	if len(added) > 0 {
+		pn.eachObserver(func(o Observer) { o.OnTokensAdded(p, added) })
+	}
+	if len(removed) > 0 {
+		pn.eachObserver(func(o Observer) { o.OnTokensRemoved(p, removed) })
+	}
+	var err error
+	if external {
+		if len(added) > 0 || len(removed) > 0 {
+			pn.eachObserver(func(o Observer) { o.OnExternalChange(p, added, removed) })
+		}
+		err = pn.checkpoint()
+	}
+
+	pn.changedMu.Lock()
+	pn.changed[p] = struct{}{}
+	if err != nil && pn.checkpointErr == nil {
+		pn.checkpointErr = err
+	}
+	pn.changedMu.Unlock()
+
+	select {
+	case pn.wake <- struct{}{}:
+	default:
+	}
+}
+
+// takeChanged returns and clears the set of places changed since the last call.
+func (pn *PetriNet) takeChanged() map[*Place]struct{} {
+	pn.changedMu.Lock()
+	defer pn.changedMu.Unlock()
+	changed := pn.changed
+	pn.changed = make(map[*Place]struct{})
+	return changed
+}
+
+// takeCheckpointErr returns and clears the failed checkpoint of an external change.
+func (pn *PetriNet) takeCheckpointErr() error {
+	pn.changedMu.Lock()
+	defer pn.changedMu.Unlock()
+	err := pn.checkpointErr
+	pn.checkpointErr = nil
+	return err
+}
+
+// hasChanges reports whether places changed since the scheduler last looked.
+func (pn *PetriNet) hasChanges() bool {
+	pn.changedMu.Lock()
+	defer pn.changedMu.Unlock()
+	return len(pn.changed) > 0
+}
+
+// buildAdjacency indexes, for every place, the transitions connected to it by
+// an arc. A token change then only re-evaluates the transitions attached to
+// the changed place instead of scanning the whole net.
+func (pn *PetriNet) buildAdjacency() map[*Place][]*Transition {
+	pn.mu.RLock()
+	defer pn.mu.RUnlock()
+
+	adjacency := make(map[*Place][]*Transition)
+	for _, t := range pn.Transitions {
+		for _, p := range t.placesInLockOrder() {
+			adjacency[p] = append(adjacency[p], t)
+		}
+	}
+	return adjacency
+}
+
+// scheduler holds the state of one run.
+type scheduler struct {
+	pn        *PetriNet
+	clock     Clock
+	adjacency map[*Place][]*Transition
+
+	// enabledSince records when each enabled transition became enabled (or last
+	// fired), for enabling delays. Disabled transitions have no entry.
+	enabledSince map[*Transition]time.Time
+	// due holds transitions waiting for time to pass: an enabling delay or a
+	// token that is not available yet.
+	due map[*Transition]time.Time
+
+	// deterministic runs draw the next transition with rng seeded by RunOptions.Seed.
+	deterministic bool
+	rng           *rand.Rand
+	// policy resolves conflicts between candidates; nil = map order or seeded draw.
+	policy ConflictPolicy
+
+	// timer fires at timerAt, the earliest due time when it was set. It is
+	// kept while that due time stays the earliest, so that waiting does not
+	// register a new timer with the clock on every pass.
+	timer   <-chan time.Time
+	timerAt time.Time
+}
+
+// ordered returns the transitions sorted by ID without duplicates in
+// deterministic runs or under a conflict policy, and unchanged otherwise.
+func (s *scheduler) ordered(transitions []*Transition) []*Transition {
+	if !s.deterministic && s.policy == nil {
+		return transitions
+	}
+	seen := make(map[*Transition]struct{}, len(transitions))
+	result := make([]*Transition, 0, len(transitions))
+	for _, t := range transitions {
+		if _, ok := seen[t]; !ok {
+			seen[t] = struct{}{}
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// next removes and returns the candidate to try next: chosen by the conflict
+// policy, drawn at random in deterministic runs, the first one otherwise.
+func (s *scheduler) next(candidates []*Transition) (*Transition, []*Transition) {
+	i := 0
+	switch {
+	case s.policy != nil:
+		i = s.policy.Choose(candidates, s.rng)
+	case s.deterministic:
+		i = s.rng.Intn(len(candidates))
+	}
+	return candidates[i], append(candidates[:i:i], candidates[i+1:]...)
+}
+
+// evaluate re-checks the given transitions at now and returns those that may be
+// started. Transitions that are enabled but still within their enabling delay,
+// or that wait for tokens to become available, are registered in s.due.
+// Observers are told about transitions that became enabled.
+func (s *scheduler) evaluate(transitions []*Transition, now time.Time) []*Transition {
+	transitions = s.ordered(transitions)
+	seen := make(map[*Transition]struct{}, len(transitions))
+	var result []*Transition
+	for _, t := range transitions {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		delete(s.due, t)
+		if !t.enabledAt(now) {
+			delete(s.enabledSince, t)
+			if next := t.nextAvailable(now); !next.IsZero() {
+				s.due[t] = next
+			}
+			continue
+		}
+		since, wasEnabled := s.enabledSince[t]
+		if !wasEnabled {
+			since = now
+			s.enabledSince[t] = now
+			s.pn.eachObserver(func(o Observer) { o.OnTransitionEnabled(t) })
+		}
+		if ready := since.Add(t.Delay); ready.After(now) {
+			s.due[t] = ready
+			continue
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// affected returns the transitions attached to any of the changed places.
+func (s *scheduler) affected(changed map[*Place]struct{}) []*Transition {
+	var result []*Transition
+	for p := range changed {
+		result = append(result, s.adjacency[p]...)
+	}
+	return result
+}
+
+// takeDue removes and returns the transitions whose due time has passed.
+func (s *scheduler) takeDue(now time.Time) []*Transition {
+	var ready []*Transition
+	for t, at := range s.due {
+		if !at.After(now) {
+			ready = append(ready, t)
+			delete(s.due, t)
+		}
+	}
+	return ready
+}
+
+// nextDue returns the earliest due time, or zero if no transition waits for time.
+func (s *scheduler) nextDue() time.Time {
+	var next time.Time
+	for _, at := range s.due {
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// nextAvailable returns the earliest time after now at which a token in one of
+// the input or read places becomes available, or zero if there is none.
+func (t *Transition) nextAvailable(now time.Time) time.Time {
+	var next time.Time
+	for _, arcs := range [][]*Arc{t.InputArcs, t.ReadArcs} {
+		for _, arc := range arcs {
+			at := arc.Place.nextAvailable(now)
+			if !at.IsZero() && (next.IsZero() || at.Before(next)) {
+				next = at
+			}
+		}
+	}
+	return next
+}
+
+// schedule fires transitions as token changes enable them, running the actions
+// of in-flight firings concurrently, and records the outcome in report. It
+// returns once the net is quiescent (unless opts.Continuous), a limit is
+// reached, an action fails or ctx is cancelled, always after in-flight
+// firings have finished. Timed transitions and tokens that are not available
+// yet keep the run alive and wake the scheduler through the net's clock.
+func (pn *PetriNet) schedule(ctx context.Context, opts RunOptions, report *RunReport) {
+	s := &scheduler{
+		pn:           pn,
+		clock:        pn.clock(),
+		adjacency:    pn.buildAdjacency(),
+		enabledSince: make(map[*Transition]time.Time),
+		due:          make(map[*Transition]time.Time),
+	}
+	s.policy = pn.Conflict
+	s.deterministic = opts.Deterministic
+	if s.deterministic {
+		s.rng = rand.New(rand.NewSource(opts.Seed))
+	} else {
+		s.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	maxInflight := opts.MaxConcurrency
+	if opts.Sequential || opts.Deterministic {
+		maxInflight = 1
+	}
+	done := make(chan FiringEvent) // completed or failed in-flight firings
+	inflight := 0
+	started := 0
+
+	// The first pass considers every transition; later passes only those
+	// attached to places that changed in the meantime or whose time has come.
+	// Transitions and arcs added while the net is running are picked up by the
+	// next run.
+	pn.takeChanged()
+	pn.mu.RLock()
+	all := make([]*Transition, 0, len(pn.Transitions))
+	for _, t := range pn.Transitions {
+		all = append(all, t)
+	}
+	pn.mu.RUnlock()
+	candidates := s.evaluate(all, s.clock.Now())
+
+	for {
+		if report.StopReason == "" && ctx.Err() != nil {
+			report.StopReason = StopCancelled
+		}
+		if report.StopReason == "" && opts.MaxFirings > 0 && started >= opts.MaxFirings {
+			report.StopReason = StopBudget
+		}
+		if err := pn.takeCheckpointErr(); err != nil {
+			report.Errors = append(report.Errors, err)
+			if report.StopReason == "" {
+				report.StopReason = StopFailed
+			}
+		}
+
+		// Candidates skipped because of the concurrency cap are carried over.
+		var deferred []*Transition
+		if report.StopReason == "" {
+			if s.deterministic {
+				// Drop candidates disabled in the meantime, so that the draw
+				// does not depend on when the scheduler happened to look.
+				candidates = s.evaluate(candidates, s.clock.Now())
+			}
+			for len(candidates) > 0 {
+				if maxInflight > 0 && inflight >= maxInflight {
+					deferred = candidates
+					break
+				}
+				if opts.MaxFirings > 0 && started >= opts.MaxFirings {
+					break
+				}
+				var t *Transition
+				t, candidates = s.next(candidates)
+				now := s.clock.Now()
+				f, err := t.reserve(now)
+				if err != nil {
+					var rejected *guardRejected
+					if errors.As(err, &rejected) {
+						pn.eachObserver(func(o Observer) { o.OnGuardRejected(t, rejected.tokens) })
+					}
+					continue // ErrNotReady: wait for one of its places to change
+				}
+				if _, enabled := s.enabledSince[t]; enabled {
+					s.enabledSince[t] = now // the enabling delay restarts after each firing
+				}
+				if st, ok := s.policy.(starter); ok {
+					st.Started(t)
+				}
+				inflight++
+				started++
+				startEv := f.event()
+				pn.eachObserver(func(o Observer) { o.OnFiringStarted(startEv) })
+				go func(f *firing) { done <- f.run(ctx) }(f)
+			}
+		}
+
+		if inflight == 0 {
+			if report.StopReason != "" {
+				return
+			}
+			if !opts.Continuous && len(deferred) == 0 && len(s.due) == 0 && !pn.hasChanges() {
+				report.StopReason = StopQuiescent
+				return
+			}
+		}
+
+		var timer <-chan time.Time
+		if next := s.nextDue(); !next.IsZero() && report.StopReason == "" {
+			if s.timer == nil || !next.Equal(s.timerAt) {
+				s.timer, s.timerAt = s.clock.After(next.Sub(s.clock.Now())), next
+			}
+			timer = s.timer
+		}
+
+		select {
+		case ev := <-done:
+			inflight--
+			err := s.complete(ev, report)
+			if err != nil && report.StopReason == "" {
+				if ctx.Err() != nil {
+					report.StopReason = StopCancelled
+				} else {
+					report.StopReason = StopFailed
+				}
+			}
+		case <-pn.wake:
+		case <-timer:
+			s.timer = nil
+		case <-ctx.Done():
+			if inflight > 0 {
+				// Wait for in-flight firings to observe the cancellation and roll back.
+				s.complete(<-done, report)
+				inflight--
+			}
+		}
+
+		now := s.clock.Now()
+		candidates = append(deferred, s.evaluate(append(s.affected(pn.takeChanged()), s.takeDue(now)...), now)...)
+	}
+}
+
+// complete finishes a firing of the run, recording the firing sequence of
+// deterministic runs.
+func (s *scheduler) complete(ev FiringEvent, report *RunReport) error {
+	if s.deterministic && ev.Err == nil {
+		report.Sequence = append(report.Sequence, ev.Transition.ID)
+	}
+	return s.pn.complete(ev, report)
+}
+
+// complete records a finished firing, reports it to observers and checkpoints
+// committed firings. It returns the action error or the checkpoint error.
+func (pn *PetriNet) complete(ev FiringEvent, report *RunReport) error {
+	report.record(ev)
+	if ev.Err != nil {
+		pn.eachObserver(func(o Observer) { o.OnFiringFailed(ev) })
+		return ev.Err
+	}
+	pn.eachObserver(func(o Observer) { o.OnFiringCompleted(ev) })
+
+	if err := pn.checkpoint(); err != nil {
+		report.Errors = append(report.Errors, err)
+		return err
+	}
+	return nil
+}
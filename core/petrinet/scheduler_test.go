@@ -0,0 +1,132 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestContinuousRunWakesOnExternalTokens(t *testing.T) {
+	net := pipeNet("continuous", 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	type result struct {
+		report *RunReport
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		report, err := net.Run(ctx, RunOptions{Continuous: true})
+		done <- result{report, err}
+	}()
+
+	for i := 0; i < 3; i++ {
+		net.Places["in"].AddTokens(&Token{ID: fmt.Sprintf("t-%d", i)})
+		want := i + 1
+		waitFor(t, func() bool { return net.Places["out"].TokenCount() == want })
+	}
+	cancel()
+	r := <-done
+	if !errors.Is(r.err, context.Canceled) || r.report.StopReason != StopCancelled {
+		t.Errorf("Run() = %s, %v, want cancelled", r.report.StopReason, r.err)
+	}
+	if r.report.Firings != 3 {
+		t.Errorf("report counts %d firings, want 3", r.report.Firings)
+	}
+}
+
+func TestRunRespectsConcurrencyLimits(t *testing.T) {
+	const jobs = 12
+	tests := []struct {
+		name string
+		opts RunOptions
+		max  int // highest number of actions allowed to run at once, 0 = any
+	}{
+		{"unlimited", RunOptions{}, 0},
+		{"max concurrency 1", RunOptions{MaxConcurrency: 1}, 1},
+		{"max concurrency 3", RunOptions{MaxConcurrency: 3}, 3},
+		{"sequential", RunOptions{Sequential: true, MaxConcurrency: 4}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := pipeNet("limits", jobs)
+			var running, peak atomic.Int32
+			net.Transitions["move"].Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				running.Add(-1)
+				return tokens, nil
+			}
+			report, err := net.Run(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.StopReason != StopQuiescent || report.Firings != jobs {
+				t.Errorf("run stopped %s after %d firings, want quiescent after %d", report.StopReason, report.Firings, jobs)
+			}
+			if tt.max > 0 && int(peak.Load()) > tt.max {
+				t.Errorf("%d actions ran at once, want at most %d", peak.Load(), tt.max)
+			}
+		})
+	}
+}
+
+func TestAdjacencyCoversEveryArcKind(t *testing.T) {
+	net := NewPetriNet("adjacency")
+	places := make(map[string]*Place)
+	for _, id := range []string{"in", "out", "read", "inhibit", "reset", "unrelated"} {
+		places[id] = NewPlace(id, id, -1)
+		net.AddPlace(places[id])
+	}
+	tr := NewTransition("t", "t")
+	tr.AddInputArc(places["in"], 1)
+	tr.AddOutputArc(places["out"], 1)
+	tr.AddReadArc(places["read"], 1)
+	tr.AddInhibitorArc(places["inhibit"], 1)
+	tr.AddResetArc(places["reset"])
+	net.AddTransition(tr)
+
+	adjacency := net.buildAdjacency()
+	for id, p := range places {
+		want := 1
+		if id == "unrelated" {
+			want = 0
+		}
+		if got := len(adjacency[p]); got != want {
+			t.Errorf("place %s indexes %d transitions, want %d", id, got, want)
+		}
+	}
+}
+
+func TestContinuousRunWakesOnInhibitorRelease(t *testing.T) {
+	// move is attached to pause only by an inhibitor arc; emptying pause must
+	// still re-evaluate it.
+	net := pipeNet("inhibited", 2)
+	pause := NewPlace("pause", "pause", -1)
+	net.AddPlace(pause)
+	pause.AddTokens(&Token{ID: "stop"})
+	net.Transitions["move"].AddInhibitorArc(pause, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		net.Run(ctx, RunOptions{Continuous: true})
+		close(done)
+	}()
+	defer func() { cancel(); <-done }()
+
+	time.Sleep(10 * time.Millisecond)
+	if n := net.Places["out"].TokenCount(); n != 0 {
+		t.Fatalf("out holds %d tokens while inhibited", n)
+	}
+	pause.RemoveTokens(1)
+	waitFor(t, func() bool { return net.Places["out"].TokenCount() == 2 })
+}
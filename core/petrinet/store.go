@@ -0,0 +1,216 @@
+package petrinet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNoCheckpoint is returned by Store.Load when nothing has been saved yet.
+var ErrNoCheckpoint = errors.New("no checkpoint")
+
+// Store persists markings so that a net can resume after a restart.
+type Store interface {
+	Save(m Marking) error
+	Load() (Marking, error)
+}
+
+// Codec converts Token.Data to and from bytes for a Store.
+type Codec interface {
+	Encode(data interface{}) ([]byte, error)
+	Decode(raw []byte) (interface{}, error)
+}
+
+// JSONCodec encodes token data as JSON. Decoded data uses the generic JSON
+// types (map[string]interface{}, []interface{}, float64, string, bool), so
+// tokens carrying structs need a Codec that knows their types.
+type JSONCodec struct{}
+
+func (JSONCodec) Encode(data interface{}) ([]byte, error) { return json.Marshal(data) }
+
+func (JSONCodec) Decode(raw []byte) (interface{}, error) {
+	var data interface{}
+	err := json.Unmarshal(raw, &data)
+	return data, err
+}
+
+// FileStore keeps the latest checkpoint in a single JSON file. Saves are
+// atomic: the file is written next to the target, synced and renamed over it,
+// so a crash leaves either the previous or the new checkpoint.
+type FileStore struct {
+	Path  string
+	Codec Codec // nil = JSONCodec
+}
+
+// NewFileStore creates a store writing to path.
+func NewFileStore(path string, codec Codec) *FileStore {
+	return &FileStore{Path: path, Codec: codec}
+}
+
+type storedToken struct {
+	ID          string     `json:"id"`
+	Serial      uint64     `json:"serial,omitempty"`
+	Priority    int        `json:"priority,omitempty"`
+	AvailableAt *time.Time `json:"available_at,omitempty"`
+	Data        []byte     `json:"data,omitempty"`
+}
+
+type checkpoint struct {
+	SavedAt time.Time                `json:"saved_at"`
+	Places  map[string][]storedToken `json:"places"`
+}
+
+func (s *FileStore) codec() Codec {
+	if s.Codec == nil {
+		return JSONCodec{}
+	}
+	return s.Codec
+}
+
+// Save writes m as the latest checkpoint.
+func (s *FileStore) Save(m Marking) error {
+	cp := checkpoint{SavedAt: time.Now(), Places: make(map[string][]storedToken, len(m))}
+	for id, tokens := range m {
+		stored, err := encodeTokens(s.codec(), tokens)
+		if err != nil {
+			return fmt.Errorf("encode tokens of %s: %w", id, err)
+		}
+		cp.Places[id] = stored
+	}
+
+	raw, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.Path, raw)
+}
+
+// Load reads the latest checkpoint, or returns ErrNoCheckpoint.
+func (s *FileStore) Load() (Marking, error) {
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoCheckpoint
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(raw, &cp); err != nil {
+		return nil, fmt.Errorf("read checkpoint %s: %w", s.Path, err)
+	}
+	m := make(Marking, len(cp.Places))
+	for id, stored := range cp.Places {
+		tokens, err := decodeTokens(s.codec(), stored)
+		if err != nil {
+			return nil, fmt.Errorf("decode tokens of %s: %w", id, err)
+		}
+		m[id] = tokens
+	}
+	return m, nil
+}
+
+func encodeTokens(codec Codec, tokens []*Token) ([]storedToken, error) {
+	stored := make([]storedToken, 0, len(tokens))
+	for _, tok := range tokens {
+		st := storedToken{ID: tok.ID, Serial: tok.Serial, Priority: tok.Priority}
+		if !tok.AvailableAt.IsZero() {
+			at := tok.AvailableAt
+			st.AvailableAt = &at
+		}
+		if tok.Data != nil {
+			raw, err := codec.Encode(tok.Data)
+			if err != nil {
+				return nil, fmt.Errorf("token %s: %w", tok.ID, err)
+			}
+			st.Data = raw
+		}
+		stored = append(stored, st)
+	}
+	return stored, nil
+}
+
+func decodeTokens(codec Codec, stored []storedToken) ([]*Token, error) {
+	tokens := make([]*Token, 0, len(stored))
+	for _, st := range stored {
+		tok := &Token{ID: st.ID, Serial: st.Serial, Priority: st.Priority}
+		if st.AvailableAt != nil {
+			tok.AvailableAt = *st.AvailableAt
+		}
+		if st.Data != nil {
+			data, err := codec.Decode(st.Data)
+			if err != nil {
+				return nil, fmt.Errorf("token %s: %w", st.ID, err)
+			}
+			tok.Data = data
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+// writeFileAtomic replaces path with data through a synced temporary file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Resume restores the latest checkpoint of store, if there is one, and keeps
+// checkpointing to store after every committed firing of later runs. Firings
+// that were in flight when the checkpoint was taken are not part of it (their
+// tokens are back in their input places), so they simply fire again.
+func (pn *PetriNet) Resume(store Store) error {
+	m, err := store.Load()
+	switch {
+	case errors.Is(err, ErrNoCheckpoint):
+	case err != nil:
+		return fmt.Errorf("resume %s: %w", pn.Name, err)
+	default:
+		if err := pn.Restore(m); err != nil {
+			return fmt.Errorf("resume %s: %w", pn.Name, err)
+		}
+	}
+
+	pn.mu.Lock()
+	pn.Store = store
+	pn.mu.Unlock()
+	return nil
+}
+
+// checkpoint saves the current marking to the net's store, if any.
+func (pn *PetriNet) checkpoint() error {
+	pn.mu.RLock()
+	store := pn.Store
+	pn.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	if err := store.Save(pn.Snapshot()); err != nil {
+		return fmt.Errorf("checkpoint %s: %w", pn.Name, err)
+	}
+	return nil
+}
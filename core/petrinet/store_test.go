@@ -0,0 +1,194 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	saved := Marking{
+		"in": {
+			{ID: "a", Serial: 7, Priority: 2, Data: map[string]interface{}{"text": "hello"}},
+			{ID: "b", Serial: 9, AvailableAt: at},
+		},
+		"out": {},
+	}
+	store := NewFileStore(filepath.Join(t.TempDir(), "net.json"), nil)
+
+	if _, err := store.Load(); !errors.Is(err, ErrNoCheckpoint) {
+		t.Fatalf("Load() before Save error = %v, want ErrNoCheckpoint", err)
+	}
+	if err := store.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(loaded["out"]); n != 0 {
+		t.Errorf("out holds %d tokens, want 0", n)
+	}
+	if got := tokenIDs(loaded["in"]); got != "a b" {
+		t.Fatalf("in holds %s, want a b", got)
+	}
+	a, b := loaded["in"][0], loaded["in"][1]
+	if a.Serial != 7 || a.Priority != 2 || b.Serial != 9 {
+		t.Errorf("serials/priority = %d/%d, %d, want 7/2, 9", a.Serial, a.Priority, b.Serial)
+	}
+	if data, ok := a.Data.(map[string]interface{}); !ok || data["text"] != "hello" {
+		t.Errorf("data of a = %#v, want the saved map", a.Data)
+	}
+	if !b.AvailableAt.Equal(at) {
+		t.Errorf("b available at %v, want %v", b.AvailableAt, at)
+	}
+}
+
+// failingStore refuses every save.
+type failingStore struct{}
+
+var errDiskFull = errors.New("disk full")
+
+func (failingStore) Save(Marking) error     { return errDiskFull }
+func (failingStore) Load() (Marking, error) { return nil, ErrNoCheckpoint }
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		fired   int // firings of the first run, -1 = no first run
+		wantIn  int // tokens in in after Resume
+		wantOut int
+	}{
+		{"no checkpoint", -1, 5, 0},
+		{"after a partial run", 2, 3, 2},
+		{"after a complete run", 5, 0, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewFileStore(filepath.Join(t.TempDir(), "net.json"), nil)
+			if tt.fired >= 0 {
+				first := pipeNet("job", 5)
+				if err := first.Resume(store); err != nil {
+					t.Fatal(err)
+				}
+				opts := RunOptions{Sequential: true, MaxFirings: tt.fired}
+				if _, err := first.Run(context.Background(), opts); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// A fresh process rebuilds the net with its initial marking.
+			net := pipeNet("job", 5)
+			if err := net.Resume(store); err != nil {
+				t.Fatal(err)
+			}
+			if n := net.Places["in"].TokenCount(); n != tt.wantIn {
+				t.Errorf("in holds %d tokens after Resume, want %d", n, tt.wantIn)
+			}
+			if n := net.Places["out"].TokenCount(); n != tt.wantOut {
+				t.Errorf("out holds %d tokens after Resume, want %d", n, tt.wantOut)
+			}
+			if _, err := net.Run(context.Background(), RunOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			m, err := store.Load()
+			if err != nil {
+				t.Fatalf("no checkpoint after the resumed run: %v", err)
+			}
+			if n := len(m["out"]); n != 5 {
+				t.Errorf("checkpoint holds %d tokens in out, want 5", n)
+			}
+		})
+	}
+}
+
+func TestCheckpointFailureStopsRun(t *testing.T) {
+	net := pipeNet("job", 3)
+	if err := net.Resume(failingStore{}); err != nil {
+		t.Fatal(err)
+	}
+	report, err := net.Run(context.Background(), RunOptions{Sequential: true})
+	if !errors.Is(err, errDiskFull) {
+		t.Fatalf("Run() error = %v, want %v", err, errDiskFull)
+	}
+	if report.StopReason != StopFailed {
+		t.Errorf("stop reason = %s, want %s", report.StopReason, StopFailed)
+	}
+	if report.Firings != 1 {
+		t.Errorf("run went on for %d firings after the failed checkpoint, want 1", report.Firings)
+	}
+}
+
+func TestCheckpointExternalChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		running bool // whether the tokens arrive while the net runs
+	}{
+		{"before a run", false},
+		{"while running", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewFileStore(filepath.Join(t.TempDir(), "net.json"), nil)
+			net := pipeNet("job", 0)
+			if err := net.Resume(store); err != nil {
+				t.Fatal(err)
+			}
+			// move never commits: the run is killed while its first firing waits.
+			started := make(chan struct{}, 3)
+			net.Transitions["move"].Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+				started <- struct{}{}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan struct{})
+			if tt.running {
+				go func() {
+					net.RunContinuous(ctx)
+					close(done)
+				}()
+			}
+			for i := 0; i < 3; i++ {
+				if err := net.Places["in"].AddTokens(&Token{ID: "job"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.running {
+				<-started
+				cancel()
+				<-done
+			}
+
+			// A fresh process rebuilds the net with its initial marking.
+			resumed := pipeNet("job", 0)
+			if err := resumed.Resume(store); err != nil {
+				t.Fatal(err)
+			}
+			if n := resumed.Places["in"].TokenCount(); n != 3 {
+				t.Errorf("in holds %d tokens after Resume, want 3", n)
+			}
+		})
+	}
+}
+
+func TestExternalCheckpointFailureStopsRun(t *testing.T) {
+	net := pipeNet("job", 0)
+	if err := net.Resume(failingStore{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.Places["in"].AddTokens(&Token{ID: "job"}); err != nil {
+		t.Fatal(err)
+	}
+	report, err := net.Run(context.Background(), RunOptions{Sequential: true})
+	if !errors.Is(err, errDiskFull) {
+		t.Fatalf("Run() error = %v, want %v", err, errDiskFull)
+	}
+	if report.Firings != 0 {
+		t.Errorf("run fired %d times after the failed checkpoint, want 0", report.Firings)
+	}
+}
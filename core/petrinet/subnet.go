@@ -0,0 +1,128 @@
+package petrinet
+
+import "fmt"
+
+// Refine substitutes child for the transition t of pn, making pn a flattened
+// hierarchical net. ports maps port places of child (by ID) to places of pn
+// that they are fused with: child transitions connected to a port are
+// connected to the parent place instead. Every place connected to t must be
+// the target of a port, so that the substitution transition's interface is
+// kept; ports may also fuse shared places such as resources. Port places
+// must be empty, since the parent place holds the tokens, and child
+// transitions must only be connected to places of child.
+//
+// The other places and the transitions of child are copied into pn with IDs
+// prefixed by "<t.ID>/", together with the tokens child holds, so the same
+// child can refine several transitions and stays usable on its own. t itself
+// is removed from pn. Refining a transition of child beforehand yields deeper
+// hierarchies ("outer/inner/task"). Refine fails with ErrNetRunning while pn
+// runs.
+func (pn *PetriNet) Refine(t *Transition, child *PetriNet, ports map[string]string) error {
+	if child == pn {
+		return fmt.Errorf("net %s cannot refine its own transition %s", pn.Name, t.ID)
+	}
+	initial := child.Snapshot()
+
+	pn.mu.Lock()
+	defer pn.mu.Unlock()
+	child.mu.RLock()
+	defer child.mu.RUnlock()
+
+	if pn.running > 0 {
+		return fmt.Errorf("refining %s: %w", t.ID, ErrNetRunning)
+	}
+	if pn.Transitions[t.ID] != t {
+		return fmt.Errorf("transition %s is not part of net %s", t.ID, pn.Name)
+	}
+
+	fused := make(map[*Place]*Place, len(ports))
+	fusedParents := make(map[*Place]bool, len(ports))
+	for portID, parentID := range ports {
+		port, ok := child.Places[portID]
+		if !ok {
+			return fmt.Errorf("refining %s: net %s has no port place %s", t.ID, child.Name, portID)
+		}
+		parent, ok := pn.Places[parentID]
+		if !ok {
+			return fmt.Errorf("refining %s: net %s has no place %s for port %s", t.ID, pn.Name, parentID, portID)
+		}
+		if n := len(initial[portID]); n > 0 {
+			return fmt.Errorf("refining %s: port place %s of %s holds %d tokens, the tokens of %s are those of %s", t.ID, portID, child.Name, n, portID, parentID)
+		}
+		fused[port] = parent
+		fusedParents[parent] = true
+	}
+	for _, arcs := range [][]*Arc{t.InputArcs, t.OutputArcs, t.ReadArcs, t.InhibitorArcs, t.ResetArcs} {
+		for _, arc := range arcs {
+			if !fusedParents[arc.Place] {
+				return fmt.Errorf("refining %s: place %s is connected to it but fused with no port of %s", t.ID, arc.Place.ID, child.Name)
+			}
+		}
+	}
+
+	prefix := t.ID + "/"
+	places := make(map[*Place]*Place, len(child.Places))
+	for _, cp := range child.Places {
+		if parent, ok := fused[cp]; ok {
+			places[cp] = parent
+			continue
+		}
+		id := prefix + cp.ID
+		if _, exists := pn.Places[id]; exists {
+			return fmt.Errorf("refining %s: net %s already has a place %s", t.ID, pn.Name, id)
+		}
+		p := NewPlace(id, t.Name+"/"+cp.Name, cp.Capacity)
+		p.Ordering = cp.Ordering
+		p.setTokens(initial[cp.ID])
+		places[cp] = p
+	}
+
+	transitions := make([]*Transition, 0, len(child.Transitions))
+	for _, ct := range child.Transitions {
+		for _, arcs := range [][]*Arc{ct.InputArcs, ct.OutputArcs, ct.ReadArcs, ct.InhibitorArcs, ct.ResetArcs} {
+			for _, arc := range arcs {
+				if _, ok := places[arc.Place]; !ok {
+					return fmt.Errorf("refining %s: transition %s of %s is connected to place %s, which is not part of it", t.ID, ct.ID, child.Name, arc.Place.ID)
+				}
+			}
+		}
+		id := prefix + ct.ID
+		if _, exists := pn.Transitions[id]; exists {
+			return fmt.Errorf("refining %s: net %s already has a transition %s", t.ID, pn.Name, id)
+		}
+		clone := *ct
+		clone.ID = id
+		clone.net = pn
+		clone.Name = t.Name + "/" + ct.Name
+		clone.InputArcs = remapArcs(ct.InputArcs, places)
+		clone.OutputArcs = remapArcs(ct.OutputArcs, places)
+		clone.ReadArcs = remapArcs(ct.ReadArcs, places)
+		clone.InhibitorArcs = remapArcs(ct.InhibitorArcs, places)
+		clone.ResetArcs = remapArcs(ct.ResetArcs, places)
+		transitions = append(transitions, &clone)
+	}
+
+	delete(pn.Transitions, t.ID)
+	for cp, p := range places {
+		if _, isPort := fused[cp]; !isPort {
+			pn.Places[p.ID] = p
+			p.watch(pn.placeChanged)
+		}
+	}
+	for _, clone := range transitions {
+		pn.Transitions[clone.ID] = clone
+	}
+	return nil
+}
+
+// remapArcs copies arcs, pointing them at the corresponding places.
+func remapArcs(arcs []*Arc, places map[*Place]*Place) []*Arc {
+	if arcs == nil {
+		return nil
+	}
+	result := make([]*Arc, len(arcs))
+	for i, arc := range arcs {
+		result[i] = &Arc{Place: places[arc.Place], Weight: arc.Weight}
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// reviewNets builds a parent net whose transition review moves drafts to
+// approved, and a child net with ports in and out refining it.
+func reviewNets() (parent *PetriNet, review *Transition, child *PetriNet) {
+	parent = NewPetriNet("parent")
+	drafts := NewPlace("drafts", "drafts", -1)
+	approved := NewPlace("approved", "approved", -1)
+	parent.AddPlace(drafts)
+	parent.AddPlace(approved)
+	drafts.AddTokens(&Token{ID: "draft"})
+	review = NewTransition("review", "review")
+	review.AddInputArc(drafts, 1)
+	review.AddOutputArc(approved, 1)
+	parent.AddTransition(review)
+
+	child = NewPetriNet("child")
+	in := NewPlace("in", "in", -1)
+	checked := NewPlace("checked", "checked", -1)
+	out := NewPlace("out", "out", -1)
+	for _, p := range []*Place{in, checked, out} {
+		child.AddPlace(p)
+	}
+	check := NewTransition("check", "check")
+	check.AddInputArc(in, 1)
+	check.AddOutputArc(checked, 1)
+	child.AddTransition(check)
+	sign := NewTransition("sign", "sign")
+	sign.AddInputArc(checked, 1)
+	sign.AddOutputArc(out, 1)
+	child.AddTransition(sign)
+	return parent, review, child
+}
+
+func TestRefine(t *testing.T) {
+	ports := map[string]string{"in": "drafts", "out": "approved"}
+	tests := []struct {
+		name    string
+		prepare func(parent, child *PetriNet)
+		wantErr string
+	}{
+		{"substitutes the child", func(parent, child *PetriNet) {}, ""},
+		{"marked port place", func(parent, child *PetriNet) {
+			child.Places["in"].AddTokens(&Token{ID: "stray"})
+		}, "port place in of child holds 1 tokens"},
+		{"arc to a place outside the child", func(parent, child *PetriNet) {
+			child.Transitions["sign"].AddOutputArc(NewPlace("elsewhere", "elsewhere", -1), 1)
+		}, "connected to place elsewhere, which is not part of it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, review, child := reviewNets()
+			tt.prepare(parent, child)
+			err := parent.Refine(review, child, ports)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Refine() error = %v, want %q", err, tt.wantErr)
+				}
+				if _, ok := parent.Transitions["review"]; !ok {
+					t.Error("failed Refine removed the transition")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []string{"review/check", "review/sign"} {
+				if _, ok := parent.Transitions[id]; !ok {
+					t.Errorf("missing transition %s", id)
+				}
+			}
+			if _, err := parent.Run(context.Background(), RunOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if n := parent.Places["approved"].TokenCount(); n != 1 {
+				t.Errorf("approved holds %d tokens, want 1", n)
+			}
+			if n := child.Places["in"].TokenCount(); n != 0 {
+				t.Errorf("running the parent moved tokens of the child: in holds %d", n)
+			}
+		})
+	}
+}
+
+func TestRefineWhileRunning(t *testing.T) {
+	parent, review, child := reviewNets()
+	parent.Places["drafts"].RemoveTokens(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		parent.Run(ctx, RunOptions{Continuous: true})
+		close(done)
+	}()
+	waitFor(t, func() bool {
+		parent.mu.RLock()
+		defer parent.mu.RUnlock()
+		return parent.running > 0
+	})
+	err := parent.Refine(review, child, map[string]string{"in": "drafts", "out": "approved"})
+	cancel()
+	<-done
+	if !errors.Is(err, ErrNetRunning) {
+		t.Errorf("Refine() error = %v, want ErrNetRunning", err)
+	}
+}
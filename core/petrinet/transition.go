@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-	"sync"
+	"time"
 )
 
 var (
@@ -13,21 +13,52 @@ var (
 	ErrNotReady = errors.New("transition not ready")
 )
 
+// guardRejected is returned by reserve when the guard rejects the selected
+// tokens. It matches ErrNotReady with errors.Is.
+type guardRejected struct {
+	tokens []*Token
+}
+
+func (e *guardRejected) Error() string { return "guard rejected tokens: " + ErrNotReady.Error() }
+func (e *guardRejected) Unwrap() error { return ErrNotReady }
+
 // Arc represents a connection between a place and a transition
 type Arc struct {
 	Place  *Place
-	Weight int // Number of tokens to consume/produce
+	Weight int // Number of tokens to consume/produce; the threshold for inhibitor arcs
 }
 
 // Transition represents an action that can fire
 type Transition struct {
-	ID         string
-	Name       string
-	InputArcs  []*Arc
-	OutputArcs []*Arc
-	Guard      func([]*Token) bool // Optional guard condition
-	Action     func(context.Context, []*Token) ([]*Token, error)
-	mu         sync.Mutex
+	ID            string
+	Name          string
+	InputArcs     []*Arc
+	OutputArcs    []*Arc
+	ReadArcs      []*Arc              // Require Weight tokens and pass them to the action without consuming them
+	InhibitorArcs []*Arc              // Enabled only while each place holds fewer than Weight tokens
+	ResetArcs     []*Arc              // Places emptied when the transition fires
+	Guard         func([]*Token) bool // Optional guard condition
+	BindingLimit  int                 // Max token combinations tried against Guard per attempt (0 = DefaultBindingLimit)
+	Priority      int                 // Used by the StaticPriority and WeightedRandom conflict policies
+
+	// Delay is the enabling delay: the scheduler fires the transition only once
+	// it has been continuously enabled for Delay (and again after each firing).
+	Delay time.Duration
+	// Duration is the firing duration: tokens produced by a firing become
+	// available Duration after the firing started, even if the action is faster.
+	Duration time.Duration
+	Action   func(context.Context, []*Token) ([]*Token, error)
+
+	net *PetriNet // net the transition was added to, for its clock
+}
+
+// now returns the time of the transition's net, or the wall clock if it has
+// not been added to a net.
+func (t *Transition) now() time.Time {
+	if t.net == nil {
+		return time.Now()
+	}
+	return t.net.clock().Now()
 }
 
 // NewTransition creates a new transition
@@ -50,22 +81,127 @@ func (t *Transition) AddOutputArc(place *Place, weight int) {
 	t.OutputArcs = append(t.OutputArcs, &Arc{Place: place, Weight: weight})
 }
 
-// CanFire checks if transition can fire (enough tokens in all input places)
+// AddReadArc adds a read (test) arc: the transition needs weight tokens in the
+// place and receives them after its consumed input tokens, but leaves them in
+// the place. Any number of firings may read a place concurrently; transitions
+// consuming from it wait until no read is in flight. Readers are not held
+// back for a waiting consumer, so a steady stream of overlapping reads can
+// keep consumers of the place waiting indefinitely.
+func (t *Transition) AddReadArc(place *Place, weight int) {
+	t.ReadArcs = append(t.ReadArcs, &Arc{Place: place, Weight: weight})
+}
+
+// AddInhibitorArc adds an inhibitor arc: the transition is enabled only while
+// the place holds fewer than threshold tokens. A threshold of 1 means "empty".
+// Tokens consumed by firings that have not committed yet still count, since
+// the firing may fail and put them back.
+func (t *Transition) AddInhibitorArc(place *Place, threshold int) {
+	t.InhibitorArcs = append(t.InhibitorArcs, &Arc{Place: place, Weight: threshold})
+}
+
+// AddResetArc adds a reset arc: firing the transition atomically removes every
+// token left in the place (after its own inputs have been taken). The dropped
+// tokens are reported to observers in FiringEvent.Reset.
+func (t *Transition) AddResetArc(place *Place) {
+	t.ResetArcs = append(t.ResetArcs, &Arc{Place: place})
+}
+
+// CanFire checks if transition can fire (enough available tokens in all input
+// and read places and fewer tokens than the threshold in all inhibitor places)
+// at the current time of its net's clock.
 func (t *Transition) CanFire() bool {
+	return t.enabledAt(t.now())
+}
+
+func (t *Transition) enabledAt(now time.Time) bool {
 	for _, arc := range t.InputArcs {
-		if arc.Place.TokenCount() < arc.Weight {
+		if arc.Place.AvailableCount(now) < arc.Weight {
+			return false
+		}
+	}
+	for _, arc := range t.ReadArcs {
+		if arc.Place.AvailableCount(now) < arc.Weight {
+			return false
+		}
+	}
+	for _, arc := range t.InhibitorArcs {
+		if arc.Place.markedCount() >= arc.Weight {
 			return false
 		}
 	}
 	return true
 }
 
-// Fire executes the transition
+// Fire executes the transition in three phases: reserve, execute and commit.
+// Input places are locked only while tokens are reserved and while outputs are
+// committed (or inputs restored), so a long-running Action never blocks other
+// transitions or callers of AddTokens/TokenCount on shared places.
+//
+// Fire uses the clock of the transition's net for token availability and
+// ignores Delay, which is enforced by the scheduler. Once the transition has
+// been added to a net, the firing is reported to the net's observers and
+// checkpointed like the firings of a run; the error is then the action error
+// or the checkpoint error.
 func (t *Transition) Fire(ctx context.Context) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
+	f, err := t.reserve(t.now())
+	if err != nil {
+		return err
+	}
+	if t.net == nil {
+		return f.run(ctx).Err
+	}
 
-	// Collect unique places involved (inputs + outputs) and lock deterministically to avoid races/deadlocks.
+	startEv := f.event()
+	t.net.eachObserver(func(o Observer) { o.OnFiringStarted(startEv) })
+	return t.net.complete(f.run(ctx), newRunReport(t.net.Name))
+}
+
+// firing is a transition firing whose inputs have been removed and whose output
+// capacity has been reserved, but whose outputs have not been committed yet.
+type firing struct {
+	t      *Transition
+	places []*Place // involved places in lock order
+	start  time.Time
+
+	inputTokens      []*Token // consumed tokens followed by read tokens
+	consumedPerPlace map[*Place][]*Token
+	readPerPlace     map[*Place][]*Token
+	resetPerPlace    map[*Place][]*Token // tokens dropped by reset arcs
+	claims           map[*Place]int      // slots held per place until commit/abort
+	producedPerPlace map[*Place][]*Token
+}
+
+// event describes the firing for observers.
+func (f *firing) event() FiringEvent {
+	ev := FiringEvent{
+		Transition: f.t,
+		Consumed:   make(map[string][]*Token, len(f.consumedPerPlace)),
+		Produced:   make(map[string][]*Token, len(f.producedPerPlace)),
+	}
+	for place, tokens := range f.consumedPerPlace {
+		ev.Consumed[place.ID] = tokens
+	}
+	if len(f.resetPerPlace) > 0 {
+		ev.Reset = make(map[string][]*Token, len(f.resetPerPlace))
+		for place, tokens := range f.resetPerPlace {
+			ev.Reset[place.ID] = tokens
+		}
+	}
+	if len(f.readPerPlace) > 0 {
+		ev.Read = make(map[string][]*Token, len(f.readPerPlace))
+		for place, tokens := range f.readPerPlace {
+			ev.Read[place.ID] = tokens
+		}
+	}
+	for place, tokens := range f.producedPerPlace {
+		ev.Produced[place.ID] = tokens
+	}
+	return ev
+}
+
+// placesInLockOrder collects unique places involved (inputs, outputs, reads, inhibitors and resets) sorted
+// by their lock order so concurrent firings, also of different nets, lock them without deadlocking.
+func (t *Transition) placesInLockOrder() []*Place {
 	placeSet := make(map[*Place]struct{})
 	for _, arc := range t.InputArcs {
 		placeSet[arc.Place] = struct{}{}
@@ -73,22 +209,65 @@ func (t *Transition) Fire(ctx context.Context) error {
 	for _, arc := range t.OutputArcs {
 		placeSet[arc.Place] = struct{}{}
 	}
+	for _, arc := range t.ReadArcs {
+		placeSet[arc.Place] = struct{}{}
+	}
+	for _, arc := range t.InhibitorArcs {
+		placeSet[arc.Place] = struct{}{}
+	}
+	for _, arc := range t.ResetArcs {
+		placeSet[arc.Place] = struct{}{}
+	}
 
 	orderedPlaces := make([]*Place, 0, len(placeSet))
 	for p := range placeSet {
 		orderedPlaces = append(orderedPlaces, p)
 	}
-	sort.Slice(orderedPlaces, func(i, j int) bool { return orderedPlaces[i].ID < orderedPlaces[j].ID })
+	sort.Slice(orderedPlaces, func(i, j int) bool { return orderedPlaces[i].lockSeq() < orderedPlaces[j].lockSeq() })
+	return orderedPlaces
+}
 
-	for _, p := range orderedPlaces {
+func lockPlaces(places []*Place) {
+	for _, p := range places {
 		p.mu.Lock()
 	}
-	defer func() {
-		for i := len(orderedPlaces) - 1; i >= 0; i-- {
-			orderedPlaces[i].mu.Unlock()
+}
+
+func unlockPlaces(places []*Place) {
+	for i := len(places) - 1; i >= 0; i-- {
+		places[i].mu.Unlock()
+	}
+}
+
+// reserve atomically removes the input tokens and claims output capacity.
+// It returns ErrNotReady if inputs are missing, outputs are full or the guard rejects.
+func (t *Transition) reserve(now time.Time) (*firing, error) {
+	return t.reserveMatching(now, t.Guard)
+}
+
+// reserveMatching is reserve with guard in place of t.Guard.
+func (t *Transition) reserveMatching(now time.Time, guard func([]*Token) bool) (*firing, error) {
+	orderedPlaces := t.placesInLockOrder()
+	lockPlaces(orderedPlaces)
+	f, err := t.reserveLocked(orderedPlaces, now, guard)
+	unlockPlaces(orderedPlaces)
+
+	if err != nil {
+		return nil, err
+	}
+	for _, place := range orderedPlaces {
+		removed := f.consumedPerPlace[place]
+		if reset := f.resetPerPlace[place]; len(reset) > 0 {
+			removed = append(append([]*Token(nil), removed...), reset...)
 		}
-	}()
+		place.notify(nil, removed, false)
+	}
+	return f, nil
+}
 
+// readyLocked checks input availability, in-flight readers, inhibitors and
+// output capacity. Callers must hold the locks of all involved places.
+func (t *Transition) readyLocked(now time.Time) error {
 	// Build counts per place.
 	inputCounts := make(map[*Place]int)
 	for _, arc := range t.InputArcs {
@@ -98,93 +277,267 @@ func (t *Transition) Fire(ctx context.Context) error {
 	for _, arc := range t.OutputArcs {
 		outputCounts[arc.Place] += arc.Weight
 	}
+	readCounts := make(map[*Place]int)
+	for _, arc := range t.ReadArcs {
+		readCounts[arc.Place] += arc.Weight
+	}
 
 	// Check input availability and output capacity (accounting for tokens that will be consumed then returned to same place).
+	// Tokens being read by in-flight firings cannot be consumed or reset until those firings finish.
 	for place, need := range inputCounts {
-		if len(place.Tokens) < need {
+		if place.availableLocked(now) < need || place.readers > 0 {
+			return ErrNotReady
+		}
+	}
+	for _, arc := range t.ResetArcs {
+		if arc.Place.readers > 0 {
+			return ErrNotReady
+		}
+	}
+	for place, need := range readCounts {
+		if place.availableLocked(now) < inputCounts[place]+need {
+			return ErrNotReady
+		}
+	}
+	// Tokens taken by in-flight firings still inhibit: the firing may roll back.
+	for _, arc := range t.InhibitorArcs {
+		if arc.Place.markedLocked() >= arc.Weight {
 			return ErrNotReady
 		}
 	}
 	for place, outNeed := range outputCounts {
-		current := len(place.Tokens)
-		inNeed := inputCounts[place]
-		effective := current - inNeed + outNeed
-		if place.Capacity >= 0 && effective > place.Capacity {
+		extra := outNeed - inputCounts[place]
+		if place.Capacity >= 0 && extra > 0 && len(place.Tokens)+place.pending+extra > place.Capacity {
 			return ErrNotReady
 		}
 	}
+	return nil
+}
 
-	// Consume input tokens.
-	var inputTokens []*Token
-	consumedPerPlace := make(map[*Place][]*Token)
-	for _, arc := range t.InputArcs {
-		tokens := arc.Place.Tokens[:arc.Weight]
-		arc.Place.Tokens = arc.Place.Tokens[arc.Weight:]
-		consumedPerPlace[arc.Place] = append(consumedPerPlace[arc.Place], tokens...)
-		inputTokens = append(inputTokens, tokens...)
+// fireable reports whether t could be reserved at now, guard included,
+// without reserving anything.
+func (t *Transition) fireable(now time.Time) bool {
+	orderedPlaces := t.placesInLockOrder()
+	lockPlaces(orderedPlaces)
+	defer unlockPlaces(orderedPlaces)
+	if t.readyLocked(now) != nil {
+		return false
+	}
+	_, err := t.bind(now, t.Guard)
+	return err == nil
+}
+
+func (t *Transition) reserveLocked(orderedPlaces []*Place, now time.Time, guard func([]*Token) bool) (*firing, error) {
+	if err := t.readyLocked(now); err != nil {
+		return nil, err
+	}
+	outputCounts := make(map[*Place]int)
+	for _, arc := range t.OutputArcs {
+		outputCounts[arc.Place] += arc.Weight
 	}
 
-	// Guard check: guard failure is treated as not-ready; return tokens and exit quietly.
-	if t.Guard != nil && !t.Guard(inputTokens) {
-		for place, tokens := range consumedPerPlace {
-			place.Tokens = append(tokens, place.Tokens...)
+	// Select input and read tokens; guard failure is treated as not-ready and nothing has been removed yet.
+	b, err := t.bind(now, guard)
+	if err != nil {
+		return nil, err
+	}
+	consumedPerPlace, readPerPlace := b.consumed, b.read
+
+	// Consume input tokens and hold their slots, plus any extra output capacity.
+	// Reset places are emptied; their slots are held too so that a failed action
+	// can put the tokens back.
+	resetPlaces := make(map[*Place]struct{})
+	for _, arc := range t.ResetArcs {
+		resetPlaces[arc.Place] = struct{}{}
+	}
+	resetPerPlace := make(map[*Place][]*Token)
+	claims := make(map[*Place]int, len(orderedPlaces))
+	for _, place := range orderedPlaces {
+		consumed := len(consumedPerPlace[place])
+		place.removeAt(b.consumedAt[place])
+		if _, reset := resetPlaces[place]; reset && len(place.Tokens) > 0 {
+			resetPerPlace[place] = place.Tokens
+			place.setTokens(make([]*Token, 0))
+		}
+
+		place.held = append(place.held, consumedPerPlace[place]...)
+		place.held = append(place.held, resetPerPlace[place]...)
+
+		claim := consumed + len(resetPerPlace[place])
+		if outputCounts[place] > claim {
+			claim = outputCounts[place]
 		}
-		return ErrNotReady
+		place.pending += claim
+		claims[place] = claim
+	}
+	for place := range readPerPlace {
+		place.readers++
 	}
 
-	// Execute action.
+	return &firing{
+		t:                t,
+		places:           orderedPlaces,
+		start:            now,
+		inputTokens:      b.tokens,
+		consumedPerPlace: consumedPerPlace,
+		readPerPlace:     readPerPlace,
+		resetPerPlace:    resetPerPlace,
+		claims:           claims,
+	}, nil
+}
+
+// run executes the action and commits the outputs, or rolls the firing back
+// when the action fails, and describes the finished firing.
+func (f *firing) run(ctx context.Context) FiringEvent {
+	begin := time.Now()
+	outputTokens, err := f.execute(ctx)
+	duration := time.Since(begin)
+	if err != nil {
+		f.abort()
+		ev := f.event()
+		ev.Duration = duration
+		ev.Err = fmt.Errorf("action failed for %s: %w", f.t.Name, err)
+		return ev
+	}
+	f.commit(outputTokens)
+	ev := f.event()
+	ev.Duration = duration
+	return ev
+}
+
+// execute runs the action without holding any place lock and assembles the
+// tokens to distribute over the output arcs.
+func (f *firing) execute(ctx context.Context) ([]*Token, error) {
+	t := f.t
+
 	var outputTokens []*Token
 	if t.Action != nil {
-		actionOutput, err := t.Action(ctx, inputTokens)
+		actionOutput, err := t.Action(ctx, f.inputTokens)
 		if err != nil {
-			// Roll back consumed tokens on action failure.
-			for place, tokens := range consumedPerPlace {
-				place.Tokens = append(tokens, place.Tokens...)
-			}
-			return fmt.Errorf("action failed for %s: %w", t.Name, err)
+			return nil, err
 		}
 		outputTokens = actionOutput
 	}
 
 	// Return resource tokens first (places that were both consumed and produced).
 	resourcePlaces := make(map[*Place]struct{})
-	for place := range outputCounts {
-		if _, consumed := inputCounts[place]; consumed {
-			resourcePlaces[place] = struct{}{}
+	for _, arc := range t.OutputArcs {
+		if _, consumed := f.consumedPerPlace[arc.Place]; consumed {
+			if _, seen := resourcePlaces[arc.Place]; !seen {
+				resourcePlaces[arc.Place] = struct{}{}
+				outputTokens = append(outputTokens, f.consumedPerPlace[arc.Place]...)
+			}
 		}
 	}
-	for place := range resourcePlaces {
-		outputTokens = append(outputTokens, consumedPerPlace[place]...)
-	}
 
 	// If no action was defined, pass through non-resource tokens.
 	if t.Action == nil {
-		for place, tokens := range consumedPerPlace {
-			if _, isResource := resourcePlaces[place]; isResource {
+		passed := make(map[*Place]struct{})
+		for _, arc := range t.InputArcs {
+			if _, isResource := resourcePlaces[arc.Place]; isResource {
+				continue
+			}
+			if _, done := passed[arc.Place]; done {
 				continue
 			}
-			outputTokens = append(outputTokens, tokens...)
+			passed[arc.Place] = struct{}{}
+			outputTokens = append(outputTokens, f.consumedPerPlace[arc.Place]...)
 		}
 	}
 
 	// Ensure output slice has enough tokens for all output arcs.
 	totalNeeded := 0
-	for _, need := range outputCounts {
-		totalNeeded += need
+	for _, arc := range t.OutputArcs {
+		totalNeeded += arc.Weight
+	}
+	for len(outputTokens) < totalNeeded {
+		outputTokens = append(outputTokens, &Token{ID: fmt.Sprintf("gen-%d", len(outputTokens))})
+	}
+
+	// A token returned twice, or a read token that stays in its place, is
+	// produced as a copy with a serial of its own.
+	seen := make(map[*Token]struct{}, len(outputTokens))
+	for _, tokens := range f.readPerPlace {
+		for _, tok := range tokens {
+			seen[tok] = struct{}{}
+		}
 	}
-	if len(outputTokens) < totalNeeded {
-		for len(outputTokens) < totalNeeded {
-			outputTokens = append(outputTokens, &Token{ID: fmt.Sprintf("gen-%d", len(outputTokens))})
+	for i, tok := range outputTokens {
+		if _, dup := seen[tok]; dup {
+			c := *tok
+			c.Serial = 0
+			outputTokens[i] = &c
 		}
+		seen[outputTokens[i]] = struct{}{}
 	}
 
-	// Distribute output tokens.
+	// Timed transitions hold their outputs back until the firing duration has elapsed.
+	if t.Duration > 0 {
+		ready := f.start.Add(t.Duration)
+		for _, tok := range outputTokens {
+			if tok.AvailableAt.Before(ready) {
+				tok.AvailableAt = ready
+			}
+		}
+	}
+
+	return outputTokens, nil
+}
+
+// commit releases the claimed slots and distributes output tokens.
+func (f *firing) commit(outputTokens []*Token) {
+	lockPlaces(f.places)
+	f.release()
+	produced := make(map[*Place][]*Token)
 	offset := 0
-	for _, arc := range t.OutputArcs {
+	for _, arc := range f.t.OutputArcs {
 		tokensToAdd := outputTokens[offset : offset+arc.Weight]
-		arc.Place.Tokens = append(arc.Place.Tokens, tokensToAdd...)
+		arc.Place.insert(tokensToAdd)
+		produced[arc.Place] = append(produced[arc.Place], tokensToAdd...)
 		offset += arc.Weight
 	}
+	unlockPlaces(f.places)
 
-	return nil
+	f.producedPerPlace = produced
+	for _, place := range f.places {
+		place.notify(produced[place], nil, false)
+	}
+}
+
+// abort releases the claimed slots and puts consumed and reset tokens back at the head of their places.
+func (f *firing) abort() {
+	restored := make(map[*Place][]*Token)
+	for _, place := range f.places {
+		tokens := append(append([]*Token(nil), f.consumedPerPlace[place]...), f.resetPerPlace[place]...)
+		if len(tokens) > 0 {
+			restored[place] = tokens
+		}
+	}
+
+	lockPlaces(f.places)
+	f.release()
+	for place, tokens := range restored {
+		place.restore(tokens)
+	}
+	unlockPlaces(f.places)
+
+	for _, place := range f.places {
+		place.notify(restored[place], nil, false)
+	}
+}
+
+func (f *firing) release() {
+	for place, claim := range f.claims {
+		place.pending -= claim
+		consumed, reset := f.consumedPerPlace[place], f.resetPerPlace[place]
+		switch {
+		case len(consumed) > 0:
+			place.unhold(consumed[0], len(consumed)+len(reset))
+		case len(reset) > 0:
+			place.unhold(reset[0], len(reset))
+		}
+	}
+	for place := range f.readPerPlace {
+		place.readers--
+	}
 }
@@ -0,0 +1,191 @@
+package petrinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFireDoesNotHoldPlaceLocksDuringAction(t *testing.T) {
+	net := pipeNet("slow", 1)
+	in, out := net.Places["in"], net.Places["out"]
+	started, release := make(chan struct{}), make(chan struct{})
+	net.Transitions["move"].Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+		close(started)
+		<-release
+		return tokens, nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- net.Transitions["move"].Fire(context.Background()) }()
+	<-started
+
+	// Both places stay usable while the action runs.
+	calls := make(chan struct{})
+	go func() {
+		in.AddTokens(&Token{ID: "next"})
+		out.AddTokens(&Token{ID: "other"})
+		in.TokenCount()
+		close(calls)
+	}()
+	select {
+	case <-calls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("AddTokens blocked while the action ran")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n, m := in.TokenCount(), out.TokenCount(); n != 1 || m != 2 {
+		t.Errorf("in holds %d and out %d tokens, want 1 and 2", n, m)
+	}
+}
+
+func TestFireAbortRestoresInputs(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name      string
+		action    func(context.Context, []*Token) ([]*Token, error)
+		wantErr   error
+		wantIn    []string
+		wantOut   int
+		wantSlots int // free slots left in out, whose capacity is 2
+	}{
+		{"commit", nil, nil, []string{"t-1", "t-2"}, 1, 1},
+		{"action fails", func(context.Context, []*Token) ([]*Token, error) {
+			return nil, failed
+		}, failed, []string{"t-0", "t-1", "t-2"}, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := NewPetriNet("abort")
+			in := NewPlace("in", "in", -1)
+			out := NewPlace("out", "out", 2)
+			net.AddPlace(in)
+			net.AddPlace(out)
+			for i := 0; i < 3; i++ {
+				in.AddTokens(&Token{ID: fmt.Sprintf("t-%d", i)})
+			}
+			tr := NewTransition("move", "move")
+			tr.AddInputArc(in, 1)
+			tr.AddOutputArc(out, 1)
+			tr.Action = tt.action
+			net.AddTransition(tr)
+
+			if err := tr.Fire(context.Background()); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fire() error = %v, want %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, tok := range in.Tokens {
+				ids = append(ids, tok.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIn) {
+				t.Errorf("in holds %v, want %v", ids, tt.wantIn)
+			}
+			if n := out.TokenCount(); n != tt.wantOut {
+				t.Errorf("out holds %d tokens, want %d", n, tt.wantOut)
+			}
+			if in.pending != 0 || out.pending != 0 || len(in.held) != 0 {
+				t.Errorf("claims left behind: in pending %d held %d, out pending %d", in.pending, len(in.held), out.pending)
+			}
+			if !out.CanAccept(tt.wantSlots) || out.CanAccept(tt.wantSlots+1) {
+				t.Errorf("out does not have exactly %d free slots", tt.wantSlots)
+			}
+		})
+	}
+}
+
+func TestReservedOutputCapacity(t *testing.T) {
+	// Two transitions share an output place with room for one token: while
+	// the first firing runs, its slot is claimed and the second cannot start.
+	net := NewPetriNet("capacity")
+	a, b := NewPlace("a", "a", -1), NewPlace("b", "b", -1)
+	out := NewPlace("out", "out", 1)
+	for _, p := range []*Place{a, b, out} {
+		net.AddPlace(p)
+	}
+	a.AddTokens(&Token{ID: "a"})
+	b.AddTokens(&Token{ID: "b"})
+	started, release := make(chan struct{}), make(chan struct{})
+	first := NewTransition("first", "first")
+	first.AddInputArc(a, 1)
+	first.AddOutputArc(out, 1)
+	first.Action = func(ctx context.Context, tokens []*Token) ([]*Token, error) {
+		close(started)
+		<-release
+		return tokens, nil
+	}
+	second := NewTransition("second", "second")
+	second.AddInputArc(b, 1)
+	second.AddOutputArc(out, 1)
+	net.AddTransition(first)
+	net.AddTransition(second)
+
+	done := make(chan error, 1)
+	go func() { done <- first.Fire(context.Background()) }()
+	<-started
+	if err := second.Fire(context.Background()); !errors.Is(err, ErrNotReady) {
+		t.Errorf("second Fire() error = %v, want ErrNotReady", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := b.TokenCount(); n != 1 {
+		t.Errorf("b holds %d tokens, want its token back", n)
+	}
+}
+
+// TestConcurrentFiringsConserveTokens fires transitions in a ring from many
+// goroutines at once; run it with -race.
+func TestConcurrentFiringsConserveTokens(t *testing.T) {
+	const places, tokens = 4, 20
+	net := NewPetriNet("ring")
+	ring := make([]*Place, places)
+	for i := range ring {
+		ring[i] = NewPlace(fmt.Sprintf("p%d", i), "p", -1)
+		net.AddPlace(ring[i])
+	}
+	for i := 0; i < tokens; i++ {
+		ring[0].AddTokens(&Token{ID: fmt.Sprintf("t-%d", i)})
+	}
+	var transitions []*Transition
+	for i := range ring {
+		tr := NewTransition(fmt.Sprintf("t%d", i), "t")
+		tr.AddInputArc(ring[i], 1)
+		tr.AddOutputArc(ring[(i+1)%places], 1)
+		tr.Action = func(ctx context.Context, in []*Token) ([]*Token, error) {
+			time.Sleep(10 * time.Microsecond)
+			return in, nil
+		}
+		net.AddTransition(tr)
+		transitions = append(transitions, tr)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				tr := transitions[(g+i)%places]
+				if err := tr.Fire(context.Background()); err != nil && !errors.Is(err, ErrNotReady) {
+					t.Error(err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, p := range ring {
+		total += p.TokenCount()
+	}
+	if total != tokens {
+		t.Errorf("ring holds %d tokens, want %d", total, tokens)
+	}
+}
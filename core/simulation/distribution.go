@@ -0,0 +1,60 @@
+package simulation
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Distribution samples firing durations for timed transitions.
+type Distribution interface {
+	Sample(rng *rand.Rand) time.Duration
+}
+
+// Exponential returns a distribution with the given mean (the classic GSPN timing).
+func Exponential(mean time.Duration) Distribution {
+	return exponential{mean: mean}
+}
+
+type exponential struct{ mean time.Duration }
+
+func (d exponential) Sample(rng *rand.Rand) time.Duration {
+	return time.Duration(rng.ExpFloat64() * float64(d.mean))
+}
+
+// Uniform returns a distribution uniform over [min, max).
+func Uniform(min, max time.Duration) Distribution {
+	return uniform{min: min, max: max}
+}
+
+type uniform struct{ min, max time.Duration }
+
+func (d uniform) Sample(rng *rand.Rand) time.Duration {
+	if d.max <= d.min {
+		return d.min
+	}
+	return d.min + time.Duration(rng.Int63n(int64(d.max-d.min)))
+}
+
+// Empirical returns a distribution that draws uniformly from observed durations,
+// for example action latencies collected from RunReport or production logs.
+func Empirical(samples ...time.Duration) Distribution {
+	return empirical{samples: append([]time.Duration(nil), samples...)}
+}
+
+type empirical struct{ samples []time.Duration }
+
+func (d empirical) Sample(rng *rand.Rand) time.Duration {
+	if len(d.samples) == 0 {
+		return 0
+	}
+	return d.samples[rng.Intn(len(d.samples))]
+}
+
+// Deterministic returns a distribution that always yields d.
+func Deterministic(d time.Duration) Distribution {
+	return deterministic(d)
+}
+
+type deterministic time.Duration
+
+func (d deterministic) Sample(*rand.Rand) time.Duration { return time.Duration(d) }
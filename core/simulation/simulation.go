@@ -0,0 +1,802 @@
+// Package simulation runs a PetriNet as a generalized stochastic Petri net
+// (GSPN) on a virtual clock for capacity planning. Actions and guards are not
+// executed; each firing takes a duration sampled from its transition's
+// distribution and only token counts are tracked.
+package simulation
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+// DefaultMaxEvents bounds the firings of a single replication.
+const DefaultMaxEvents = 1_000_000
+
+var (
+	// ErrMaxEvents is returned when a replication exceeds Config.MaxEvents
+	// before reaching the horizon.
+	ErrMaxEvents = errors.New("simulation exceeded max events")
+
+	// ErrUnsupported is returned for nets whose estimates would not follow
+	// GSPN semantics; see Config.
+	ErrUnsupported = errors.New("net not supported by the simulator")
+
+	// ErrZeroTimeLoop is returned when immediate transitions return to a
+	// marking without time passing, so they would fire forever.
+	ErrZeroTimeLoop = errors.New("immediate transitions loop in zero time")
+)
+
+// Config configures a simulation.
+//
+// A transition is timed when it has a distribution in Durations or a non-zero
+// Transition.Duration (used as a deterministic duration); otherwise it is
+// immediate. Following GSPN rules, enabled immediate transitions always fire,
+// in zero time, before any timed transition starts, and conflicts between them
+// are resolved randomly in proportion to Weights.
+//
+// Timed transitions follow the GSPN race policy with infinite-server
+// semantics: every enabling of a timed transition runs a timer of its own,
+// sampled from its distribution, and the transition fires when the timer
+// expires, consuming its inputs and producing its outputs at once. Timed
+// transitions enabled by the same tokens therefore race and the first timer
+// to expire wins. Timers of transitions that a firing disables are discarded
+// and sampled anew once the transition is enabled again (resampling policy).
+// Resources, places that every consumer refills such as api_tokens, are the
+// exception: a timer takes their tokens when it starts and returns them when
+// it fires, so that they are held for the duration of the firing as in the
+// engine and their utilization can be measured. Timed transitions without
+// input places are sources and run one timer at a time.
+//
+// Run rejects with ErrUnsupported enabling delays (Transition.Delay), which
+// are not simulated, and immediate transitions without input or inhibitor
+// places, which would fire forever at time zero.
+type Config struct {
+	Durations    map[string]Distribution // Per transition ID
+	Weights      map[string]float64      // Conflict weights of immediate transitions per ID, default 1
+	Horizon      time.Duration           // Simulated time per replication
+	Replications int                     // Independent runs, default 1; at least 2 for confidence intervals
+	Seed         int64                   // Replication i uses Seed+i
+	MaxEvents    int                     // Firings per replication, 0 = DefaultMaxEvents
+
+	// Resources lists places whose utilization is reported. When empty, every
+	// initially marked place that all its consumers return tokens to is used
+	// (for example the api_tokens semaphore of a workflow).
+	Resources []string
+}
+
+// Result aggregates the replications of a simulation. All estimates are
+// means across replications with 95% confidence intervals.
+type Result struct {
+	Net          string
+	Horizon      time.Duration
+	Replications int
+	Occupancy    map[string]Estimate // Time-averaged token count per place ID; inputs of a timer stay until it fires
+	Throughput   map[string]Estimate // Completed firings per simulated second per transition ID
+	Utilization  map[string]Estimate // Time-averaged fraction of resource tokens in use per place ID
+}
+
+type arc struct {
+	place  int
+	weight int
+}
+
+type transition struct {
+	id      string
+	in      []arc
+	out     []arc
+	read    []arc
+	inhibit []arc
+	reset   []int
+	dist    Distribution
+	weight  float64
+	source  bool
+}
+
+type model struct {
+	places    []string
+	capacity  []int
+	initial   []int
+	held      []bool // Resource places, whose tokens timers hold until they fire
+	trans     []*transition
+	resources []int
+}
+
+// Run simulates net according to cfg. The current marking of net is the
+// initial marking of every replication; net itself is not modified.
+func Run(net *petrinet.PetriNet, cfg Config) (*Result, error) {
+	if cfg.Horizon <= 0 {
+		return nil, fmt.Errorf("simulation horizon must be positive, got %v", cfg.Horizon)
+	}
+	if cfg.Replications <= 0 {
+		cfg.Replications = 1
+	}
+	if cfg.MaxEvents <= 0 {
+		cfg.MaxEvents = DefaultMaxEvents
+	}
+
+	m, err := newModel(net, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	occupancy := make([][]float64, len(m.places))
+	throughput := make([][]float64, len(m.trans))
+	utilization := make([][]float64, len(m.resources))
+	for i := 0; i < cfg.Replications; i++ {
+		rep, err := m.replicate(cfg, rand.New(rand.NewSource(cfg.Seed+int64(i))))
+		if err != nil {
+			return nil, fmt.Errorf("replication %d: %w", i, err)
+		}
+		for p := range m.places {
+			occupancy[p] = append(occupancy[p], rep.occupancy[p])
+		}
+		for t := range m.trans {
+			throughput[t] = append(throughput[t], rep.throughput[t])
+		}
+		for r, p := range m.resources {
+			utilization[r] = append(utilization[r], 1-rep.occupancy[p]/float64(m.initial[p]))
+		}
+	}
+
+	result := &Result{
+		Net:          net.Name,
+		Horizon:      cfg.Horizon,
+		Replications: cfg.Replications,
+		Occupancy:    make(map[string]Estimate, len(m.places)),
+		Throughput:   make(map[string]Estimate, len(m.trans)),
+		Utilization:  make(map[string]Estimate, len(m.resources)),
+	}
+	for p, id := range m.places {
+		result.Occupancy[id] = estimate(occupancy[p])
+	}
+	for t, tr := range m.trans {
+		result.Throughput[tr.id] = estimate(throughput[t])
+	}
+	for r, p := range m.resources {
+		result.Utilization[m.places[p]] = estimate(utilization[r])
+	}
+	return result, nil
+}
+
+// newModel flattens net into count-based arrays, sorted by ID so that a seed
+// always reproduces the same simulation.
+func newModel(net *petrinet.PetriNet, cfg Config) (*model, error) {
+	m := &model{}
+	index := make(map[*petrinet.Place]int, len(net.Places))
+	for id := range net.Places {
+		m.places = append(m.places, id)
+	}
+	sort.Strings(m.places)
+	for i, id := range m.places {
+		place := net.Places[id]
+		index[place] = i
+		m.capacity = append(m.capacity, place.Capacity)
+		m.initial = append(m.initial, place.TokenCount())
+	}
+
+	lookup := func(t *petrinet.Transition, p *petrinet.Place) (int, error) {
+		i, ok := index[p]
+		if !ok {
+			return 0, fmt.Errorf("transition %s references place %s that is not part of the net", t.ID, p.ID)
+		}
+		return i, nil
+	}
+	arcs := func(t *petrinet.Transition, list []*petrinet.Arc) ([]arc, error) {
+		var out []arc
+		for _, a := range list {
+			p, err := lookup(t, a.Place)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, arc{place: p, weight: a.Weight})
+		}
+		return out, nil
+	}
+
+	ids := make([]string, 0, len(net.Transitions))
+	for id := range net.Transitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		t := net.Transitions[id]
+		if t.Delay > 0 {
+			return nil, fmt.Errorf("%w: transition %s has an enabling delay", ErrUnsupported, id)
+		}
+		tr := &transition{id: id, dist: cfg.Durations[id], weight: 1}
+		if tr.dist == nil && t.Duration > 0 {
+			tr.dist = Deterministic(t.Duration)
+		}
+		if w, ok := cfg.Weights[id]; ok {
+			if w <= 0 {
+				return nil, fmt.Errorf("weight of transition %s must be positive, got %v", id, w)
+			}
+			tr.weight = w
+		}
+
+		var err error
+		if tr.in, err = arcs(t, t.InputArcs); err != nil {
+			return nil, err
+		}
+		if tr.out, err = arcs(t, t.OutputArcs); err != nil {
+			return nil, err
+		}
+		if tr.read, err = arcs(t, t.ReadArcs); err != nil {
+			return nil, err
+		}
+		if tr.inhibit, err = arcs(t, t.InhibitorArcs); err != nil {
+			return nil, err
+		}
+		for _, a := range t.ResetArcs {
+			p, err := lookup(t, a.Place)
+			if err != nil {
+				return nil, err
+			}
+			tr.reset = append(tr.reset, p)
+		}
+		tr.source = len(tr.in) == 0
+		m.trans = append(m.trans, tr)
+	}
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	m.held = make([]bool, len(m.places))
+	for p := range m.places {
+		consumed, refilled := m.refilled(p)
+		m.held[p] = consumed && refilled
+	}
+
+	if len(cfg.Resources) > 0 {
+		for _, id := range cfg.Resources {
+			p, ok := net.Places[id]
+			if !ok {
+				return nil, fmt.Errorf("resource place %s not found", id)
+			}
+			if m.initial[index[p]] == 0 {
+				return nil, fmt.Errorf("resource place %s has no tokens", id)
+			}
+			m.resources = append(m.resources, index[p])
+		}
+	} else {
+		m.resources = m.detectResources()
+	}
+	return m, nil
+}
+
+// detectResources returns the initially marked places that are consumed by at
+// least one transition and that every consuming transition refills.
+func (m *model) detectResources() []int {
+	var resources []int
+	for p := range m.places {
+		if consumed, refilled := m.refilled(p); m.initial[p] > 0 && consumed && refilled {
+			resources = append(resources, p)
+		}
+	}
+	return resources
+}
+
+// refilled reports whether any transition consumes from p and whether every
+// such transition puts back as many tokens as it takes.
+func (m *model) refilled(p int) (consumed, refilled bool) {
+	refilled = true
+	for _, t := range m.trans {
+		in, out := 0, 0
+		for _, a := range t.in {
+			if a.place == p {
+				in += a.weight
+			}
+		}
+		for _, a := range t.out {
+			if a.place == p {
+				out += a.weight
+			}
+		}
+		for _, r := range t.reset {
+			if r == p {
+				consumed, refilled = true, false
+			}
+		}
+		if in > 0 {
+			consumed = true
+			refilled = refilled && out == in
+		}
+	}
+	return consumed, refilled
+}
+
+// check rejects the nets whose estimates would not follow GSPN semantics;
+// see Config.
+func (m *model) check() error {
+	for _, t := range m.trans {
+		if t.dist == nil && t.source && len(t.inhibit) == 0 {
+			return fmt.Errorf("%w: immediate transition %s has no input places and would fire forever at time zero; give it a duration", ErrUnsupported, t.id)
+		}
+	}
+	return nil
+}
+
+// replication is the outcome of one simulated run.
+type replication struct {
+	occupancy  []float64 // per place
+	throughput []float64 // per transition
+}
+
+// timer is an enabling of a timed transition that fires at a virtual time
+// unless the transition is disabled first. It holds the resource tokens the
+// firing takes and the capacity its outputs need.
+type timer struct {
+	at        time.Duration
+	seq       int
+	t         int
+	held      map[int]int // Resource tokens taken per place
+	claims    map[int]int // Slots claimed per place until the timer fires
+	cancelled bool
+}
+
+type agenda []*timer
+
+func (a agenda) Len() int { return len(a) }
+func (a agenda) Less(i, j int) bool {
+	if a[i].at != a[j].at {
+		return a[i].at < a[j].at
+	}
+	return a[i].seq < a[j].seq
+}
+func (a agenda) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a *agenda) Push(x any)   { *a = append(*a, x.(*timer)) }
+func (a *agenda) Pop() any {
+	old := *a
+	c := old[len(old)-1]
+	*a = old[:len(old)-1]
+	return c
+}
+
+// state is the marking of one replication.
+type state struct {
+	m       *model
+	counts  []int
+	pending []int // Slots claimed by running timers, as in the engine
+	area    []float64
+	fired   []int
+	now     time.Duration
+	events  agenda     // Running and cancelled timers
+	timers  [][]*timer // Running timers per transition, oldest first
+	seq     int
+}
+
+func (m *model) replicate(cfg Config, rng *rand.Rand) (*replication, error) {
+	s := &state{
+		m:       m,
+		counts:  append([]int(nil), m.initial...),
+		pending: make([]int, len(m.places)),
+		area:    make([]float64, len(m.places)),
+		fired:   make([]int, len(m.trans)),
+		timers:  make([][]*timer, len(m.trans)),
+	}
+
+	firings := 0
+	for {
+		// Vanishing markings first: fire immediate transitions, then start a
+		// timer for every enabling of a timed transition in the tangible
+		// marking. The first time immediate firings return to a marking, check
+		// that they can still stop.
+		vanishing := map[string]bool{s.key(): true}
+		trapChecked := false
+		for {
+			if t := s.choose(rng, true); t >= 0 {
+				s.fireImmediate(t)
+				s.reconcile()
+				key := s.key()
+				if vanishing[key] && !trapChecked {
+					if s.timelessTrap() {
+						return nil, fmt.Errorf("%w: %s fired back to marking %s at %v", ErrZeroTimeLoop, m.trans[t].id, key, s.now)
+					}
+					trapChecked = true
+				}
+				vanishing[key] = true
+			} else if t := s.choose(rng, false); t >= 0 {
+				s.start(t, rng)
+				s.reconcile()
+				vanishing, trapChecked = map[string]bool{s.key(): true}, false
+			} else {
+				break
+			}
+			if firings++; firings > cfg.MaxEvents {
+				return nil, fmt.Errorf("%w (%d) at %v", ErrMaxEvents, cfg.MaxEvents, s.now)
+			}
+		}
+
+		for len(s.events) > 0 && s.events[0].cancelled {
+			heap.Pop(&s.events)
+		}
+		if len(s.events) == 0 || s.events[0].at > cfg.Horizon {
+			break
+		}
+		tm := heap.Pop(&s.events).(*timer)
+		s.advance(tm.at)
+		s.fire(tm)
+		s.reconcile()
+	}
+	s.advance(cfg.Horizon)
+
+	seconds := cfg.Horizon.Seconds()
+	rep := &replication{
+		occupancy:  make([]float64, len(m.places)),
+		throughput: make([]float64, len(m.trans)),
+	}
+	for p := range m.places {
+		rep.occupancy[p] = s.area[p] / seconds
+	}
+	for t := range m.trans {
+		rep.throughput[t] = float64(s.fired[t]) / seconds
+	}
+	return rep, nil
+}
+
+// trapSearchLimit bounds the markings explored by timelessTrap.
+const trapSearchLimit = 10000
+
+// timelessTrap reports whether immediate firings from the current marking can
+// never reach a marking without enabled immediate transitions, so that time
+// would never pass again. It gives up (false) after trapSearchLimit markings.
+func (s *state) timelessTrap() bool {
+	saved := s.counts
+	defer func() { s.counts = saved }()
+
+	seen := make(map[string]bool)
+	queue := [][]int{append([]int(nil), saved...)}
+	for len(queue) > 0 {
+		if len(seen) >= trapSearchLimit {
+			return false
+		}
+		counts := queue[0]
+		queue = queue[1:]
+		s.counts = counts
+		key := s.key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		stops := true
+		for _, t := range s.m.trans {
+			if t.dist != nil || !s.enabled(t) {
+				continue
+			}
+			stops = false
+			s.counts = append([]int(nil), counts...)
+			s.remove(t)
+			s.produce(t)
+			queue = append(queue, s.counts)
+			s.counts = counts
+		}
+		if stops {
+			return false
+		}
+	}
+	return true
+}
+
+// key describes the token counts of the marking.
+func (s *state) key() string {
+	parts := make([]string, 0, len(s.counts))
+	for p, n := range s.counts {
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%s:%d", s.m.places[p], n))
+		}
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// advance moves the virtual clock to at, integrating place occupancy.
+func (s *state) advance(at time.Duration) {
+	dt := (at - s.now).Seconds()
+	for p, n := range s.counts {
+		s.area[p] += float64(n) * dt
+	}
+	s.now = at
+}
+
+// enabled reports whether immediate transition t can fire in the current
+// marking, with the same rules the engine applies to counts: inputs and reads
+// present, inhibitors below threshold, and room for the outputs in every
+// bounded place.
+func (s *state) enabled(t *transition) bool {
+	need := make(map[int]int, len(t.in)+len(t.read))
+	for _, a := range t.in {
+		need[a.place] += a.weight
+	}
+	for _, a := range t.read {
+		need[a.place] += a.weight
+	}
+	for p, n := range need {
+		if s.counts[p] < n {
+			return false
+		}
+	}
+	for _, a := range t.inhibit {
+		if s.counts[a.place] >= a.weight {
+			return false
+		}
+	}
+	produced := make(map[int]int, len(t.out))
+	for _, a := range t.out {
+		produced[a.place] += a.weight
+	}
+	for p, n := range produced {
+		consumed := s.consumed(t, p)
+		if s.m.capacity[p] >= 0 && n > consumed && s.counts[p]-consumed+n+s.pending[p] > s.m.capacity[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// consumed returns how many tokens a firing of t removes from p.
+func (s *state) consumed(t *transition, p int) int {
+	n := 0
+	for _, a := range t.in {
+		if a.place == p {
+			n += a.weight
+		}
+	}
+	for _, r := range t.reset {
+		if r == p {
+			n = s.counts[p]
+		}
+	}
+	return n
+}
+
+// degree returns how many timers of timed transition t the marking supports:
+// one per set of the inputs it consumes when it fires, at most one for a
+// source, and none while a read arc lacks tokens or an inhibitor arc blocks.
+// Resource inputs do not limit the degree; every timer holds its own.
+func (s *state) degree(t *transition) int {
+	for _, a := range t.inhibit {
+		if s.counts[a.place] >= a.weight {
+			return 0
+		}
+	}
+	need := make(map[int]int, len(t.in))
+	for _, a := range t.in {
+		if !s.m.held[a.place] {
+			need[a.place] += a.weight
+		}
+	}
+	read := make(map[int]int, len(t.read))
+	for _, a := range t.read {
+		read[a.place] += a.weight
+	}
+	for p, n := range read {
+		if s.counts[p] < n+need[p] {
+			return 0
+		}
+	}
+	degree := math.MaxInt
+	if t.source {
+		degree = 1
+	}
+	for p, n := range need {
+		if d := (s.counts[p] - read[p]) / n; d < degree {
+			degree = d
+		}
+	}
+	return degree
+}
+
+// takes returns the resource tokens a timer of t holds per place.
+func (s *state) takes(t *transition) map[int]int {
+	takes := make(map[int]int)
+	for _, a := range t.in {
+		if s.m.held[a.place] {
+			takes[a.place] += a.weight
+		}
+	}
+	return takes
+}
+
+// claims returns the slots a timer of t claims per place until it fires: its
+// resource tokens, and room for what its outputs add to a place beyond what
+// it consumes there, matching the engine's reservation.
+func (s *state) claims(t *transition) map[int]int {
+	claims := s.takes(t)
+	added := make(map[int]int)
+	for _, a := range t.out {
+		added[a.place] += a.weight
+	}
+	for _, a := range t.in {
+		if !s.m.held[a.place] {
+			added[a.place] -= a.weight
+		}
+	}
+	for p, n := range added {
+		if n > claims[p] {
+			claims[p] = n
+		}
+	}
+	return claims
+}
+
+// startable reports whether another timer of timed transition i can start:
+// the marking supports it, its resource tokens are there and its outputs fit.
+func (s *state) startable(i int) bool {
+	t := s.m.trans[i]
+	if len(s.timers[i]) >= s.degree(t) {
+		return false
+	}
+	takes := s.takes(t)
+	for p, n := range takes {
+		if s.counts[p] < n {
+			return false
+		}
+	}
+	for p, claim := range s.claims(t) {
+		if s.m.capacity[p] >= 0 && s.counts[p]-takes[p]+s.pending[p]+claim > s.m.capacity[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// choose picks an enabled immediate transition, or a timed transition that
+// can start another timer, at random in proportion to its weight, or returns
+// -1 if there is none.
+func (s *state) choose(rng *rand.Rand, immediate bool) int {
+	var candidates []int
+	total := 0.0
+	for i, t := range s.m.trans {
+		if (t.dist == nil) != immediate {
+			continue
+		}
+		if immediate && !s.enabled(t) || !immediate && !s.startable(i) {
+			continue
+		}
+		candidates = append(candidates, i)
+		total += t.weight
+	}
+	if len(candidates) == 0 {
+		return -1
+	}
+	x := rng.Float64() * total
+	for _, i := range candidates {
+		if x -= s.m.trans[i].weight; x < 0 {
+			return i
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+func (s *state) remove(t *transition) {
+	for _, a := range t.in {
+		s.counts[a.place] -= a.weight
+	}
+	for _, p := range t.reset {
+		s.counts[p] = 0
+	}
+}
+
+func (s *state) produce(t *transition) {
+	for _, a := range t.out {
+		s.counts[a.place] += a.weight
+	}
+}
+
+func (s *state) fireImmediate(i int) {
+	t := s.m.trans[i]
+	s.remove(t)
+	s.produce(t)
+	s.fired[i]++
+}
+
+// start starts a timer of timed transition i, taking its resource tokens.
+func (s *state) start(i int, rng *rand.Rand) {
+	t := s.m.trans[i]
+	tm := &timer{t: i, held: s.takes(t), claims: s.claims(t)}
+	for p, n := range tm.held {
+		s.counts[p] -= n
+	}
+	for p, n := range tm.claims {
+		s.pending[p] += n
+	}
+	s.seq++
+	tm.seq = s.seq
+	tm.at = s.now + t.dist.Sample(rng)
+	s.timers[i] = append(s.timers[i], tm)
+	heap.Push(&s.events, tm)
+}
+
+// fire fires the transition of an expired timer: its other inputs are
+// consumed now and its outputs, resource tokens included, produced.
+func (s *state) fire(tm *timer) {
+	t := s.m.trans[tm.t]
+	running := s.timers[tm.t]
+	for i, other := range running {
+		if other == tm {
+			s.timers[tm.t] = append(running[:i:i], running[i+1:]...)
+			break
+		}
+	}
+	for p, n := range tm.claims {
+		s.pending[p] -= n
+	}
+	for _, a := range t.in {
+		if !s.m.held[a.place] {
+			s.counts[a.place] -= a.weight
+		}
+	}
+	for _, p := range t.reset {
+		s.counts[p] = 0
+	}
+	s.produce(t)
+	s.fired[tm.t]++
+}
+
+// reconcile discards the newest timers of every timed transition beyond what
+// the marking supports and returns their resource tokens. Since returned
+// tokens can disable further timers through read and inhibitor arcs, it
+// repeats until every transition is within its degree.
+func (s *state) reconcile() {
+	for changed := true; changed; {
+		changed = false
+		for i, t := range s.m.trans {
+			if t.dist == nil {
+				continue
+			}
+			for degree := s.degree(t); len(s.timers[i]) > degree; {
+				last := len(s.timers[i]) - 1
+				s.cancel(s.timers[i][last])
+				s.timers[i] = s.timers[i][:last]
+				changed = true
+			}
+		}
+	}
+}
+
+// cancel discards a running timer, returning what it holds.
+func (s *state) cancel(tm *timer) {
+	for p, n := range tm.held {
+		s.counts[p] += n
+	}
+	for p, n := range tm.claims {
+		s.pending[p] -= n
+	}
+	tm.cancelled = true
+}
+
+// PrintSummary writes the estimates of r to w.
+func (r *Result) PrintSummary(w io.Writer) {
+	fmt.Fprintf(w, "Simulation of %s: %d replications of %v\n", r.Net, r.Replications, r.Horizon)
+
+	fmt.Fprintln(w, "  Throughput (firings/s):")
+	for _, id := range sortedKeys(r.Throughput) {
+		fmt.Fprintf(w, "    %s: %v\n", id, r.Throughput[id])
+	}
+	fmt.Fprintln(w, "  Mean occupancy (tokens):")
+	for _, id := range sortedKeys(r.Occupancy) {
+		fmt.Fprintf(w, "    [%s]: %v\n", id, r.Occupancy[id])
+	}
+	if len(r.Utilization) > 0 {
+		fmt.Fprintln(w, "  Resource utilization:")
+		for _, id := range sortedKeys(r.Utilization) {
+			fmt.Fprintf(w, "    [%s]: %v\n", id, r.Utilization[id])
+		}
+	}
+}
+
+func sortedKeys(m map[string]Estimate) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,182 @@
+package simulation
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+// netBuilder assembles small nets for the tests.
+type netBuilder struct{ net *petrinet.PetriNet }
+
+func newNet() *netBuilder { return &netBuilder{net: petrinet.NewPetriNet("sim")} }
+
+func (b *netBuilder) place(id string, tokens, capacity int) *petrinet.Place {
+	p := petrinet.NewPlace(id, id, capacity)
+	for i := 0; i < tokens; i++ {
+		p.AddTokens(&petrinet.Token{ID: id})
+	}
+	b.net.AddPlace(p)
+	return p
+}
+
+func (b *netBuilder) transition(id string, in, out []*petrinet.Place) *petrinet.Transition {
+	t := petrinet.NewTransition(id, id)
+	for _, p := range in {
+		t.AddInputArc(p, 1)
+	}
+	for _, p := range out {
+		t.AddOutputArc(p, 1)
+	}
+	b.net.AddTransition(t)
+	return t
+}
+
+func ps(places ...*petrinet.Place) []*petrinet.Place { return places }
+
+func TestRunRejectsUnsupportedNets(t *testing.T) {
+	second := Deterministic(time.Second)
+	tests := []struct {
+		name      string
+		build     func() (*petrinet.PetriNet, map[string]Distribution)
+		wantErr   error
+		wantRunOK bool
+	}{
+		{"immediate source", func() (*petrinet.PetriNet, map[string]Distribution) {
+			b := newNet()
+			docs := b.place("docs", 0, -1)
+			b.transition("load_docs", nil, ps(docs))
+			return b.net, nil
+		}, ErrUnsupported, false},
+		{"timed transitions racing for a channel", func() (*petrinet.PetriNet, map[string]Distribution) {
+			b := newNet()
+			docs, out := b.place("docs", 5, -1), b.place("out", 0, -1)
+			b.transition("fast", ps(docs), ps(out))
+			b.transition("slow", ps(docs), ps(out))
+			return b.net, map[string]Distribution{"fast": second, "slow": second}
+		}, nil, true},
+		{"enabling delay", func() (*petrinet.PetriNet, map[string]Distribution) {
+			b := newNet()
+			docs, out := b.place("docs", 5, -1), b.place("out", 0, -1)
+			b.transition("wait", ps(docs), ps(out)).Delay = time.Minute
+			return b.net, nil
+		}, ErrUnsupported, false},
+		{"zero-time loop", func() (*petrinet.PetriNet, map[string]Distribution) {
+			b := newNet()
+			p, q := b.place("p", 1, -1), b.place("q", 0, -1)
+			b.transition("there", ps(p), ps(q))
+			b.transition("back", ps(q), ps(p))
+			return b.net, nil
+		}, ErrZeroTimeLoop, false},
+		{"immediate retry loop with an exit", func() (*petrinet.PetriNet, map[string]Distribution) {
+			b := newNet()
+			p, q, done := b.place("p", 1, -1), b.place("q", 0, -1), b.place("done", 0, -1)
+			b.transition("try", ps(p), ps(q))
+			b.transition("retry", ps(q), ps(p))
+			b.transition("give_up", ps(q), ps(done))
+			return b.net, nil
+		}, nil, true},
+		{"timed transitions sharing a resource", func() (*petrinet.PetriNet, map[string]Distribution) {
+			b := newNet()
+			tokens := b.place("api_tokens", 2, 2)
+			a, c, out := b.place("a", 5, -1), b.place("c", 5, -1), b.place("out", 0, -1)
+			b.transition("summarize", ps(a, tokens), ps(out, tokens))
+			b.transition("classify", ps(c, tokens), ps(out, tokens))
+			return b.net, map[string]Distribution{"summarize": second, "classify": second}
+		}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net, durations := tt.build()
+			_, err := Run(net, Config{Durations: durations, Horizon: time.Minute, Seed: 1})
+			if tt.wantRunOK {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSingleServerQueue checks the estimates against an M/M/1 queue with
+// arrival rate 1/s and service rate 2/s: throughput 1/s, utilization 0.5 and
+// on average rho/(1-rho) = 1 job in the system.
+func TestSingleServerQueue(t *testing.T) {
+	b := newNet()
+	queue := b.place("queue", 0, -1)
+	server := b.place("server", 1, 1)
+	done := b.place("done", 0, -1)
+	b.transition("arrive", nil, ps(queue))
+	b.transition("serve", ps(queue, server), ps(done, server))
+
+	res, err := Run(b.net, Config{
+		Durations: map[string]Distribution{
+			"arrive": Exponential(time.Second),
+			"serve":  Exponential(500 * time.Millisecond),
+		},
+		Horizon:      2 * time.Hour,
+		Replications: 5,
+		Seed:         1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"throughput of serve", res.Throughput["serve"].Mean, 1},
+		{"utilization of server", res.Utilization["server"].Mean, 0.5},
+		// A job in service stays in queue until serve fires.
+		{"jobs in the system", res.Occupancy["queue"].Mean, 1},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 0.1*c.want {
+			t.Errorf("%s = %.3f, want %.3f ± 10%%", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestTimedTransitionsRace(t *testing.T) {
+	tests := []struct {
+		name      string
+		fast      Distribution
+		slow      Distribution
+		wantShare float64 // Share of the firings won by fast
+	}{
+		{"deterministic", Deterministic(time.Second), Deterministic(2 * time.Second), 1},
+		// Exponential races: fast wins with probability 3/(1+3).
+		{"exponential", Exponential(time.Second), Exponential(3 * time.Second), 0.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newNet()
+			docs, out := b.place("docs", 1000, -1), b.place("out", 0, -1)
+			b.transition("fast", ps(docs), ps(out))
+			b.transition("slow", ps(docs), ps(out))
+			res, err := Run(b.net, Config{
+				Durations: map[string]Distribution{"fast": tt.fast, "slow": tt.slow},
+				Horizon:   time.Hour,
+				Seed:      1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			fast, slow := res.Throughput["fast"].Mean, res.Throughput["slow"].Mean
+			if fast+slow == 0 {
+				t.Fatal("neither transition fired")
+			}
+			if share := fast / (fast + slow); math.Abs(share-tt.wantShare) > 0.05 {
+				t.Errorf("fast won %.3f of the firings, want %.2f ± 0.05", share, tt.wantShare)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+)
+
+// Estimate is a mean across replications with the half-width of its 95%
+// confidence interval (zero with a single replication).
+type Estimate struct {
+	Mean      float64
+	HalfWidth float64
+}
+
+func (e Estimate) String() string {
+	if e.HalfWidth == 0 {
+		return fmt.Sprintf("%.4g", e.Mean)
+	}
+	return fmt.Sprintf("%.4g ± %.2g", e.Mean, e.HalfWidth)
+}
+
+// tQuantiles holds the two-sided 95% Student t quantiles for 1..30 degrees of freedom.
+var tQuantiles = [...]float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// estimate computes the mean and 95% confidence half-width of samples.
+func estimate(samples []float64) Estimate {
+	n := len(samples)
+	if n == 0 {
+		return Estimate{}
+	}
+	sum := 0.0
+	for _, s := range samples {
+		sum += s
+	}
+	mean := sum / float64(n)
+	if n == 1 {
+		return Estimate{Mean: mean}
+	}
+
+	variance := 0.0
+	for _, s := range samples {
+		variance += (s - mean) * (s - mean)
+	}
+	variance /= float64(n - 1)
+
+	t := 1.960
+	if n-1 <= len(tQuantiles) {
+		t = tQuantiles[n-2]
+	}
+	return Estimate{Mean: mean, HalfWidth: t * math.Sqrt(variance/float64(n))}
+}
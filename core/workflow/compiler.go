@@ -17,6 +17,7 @@ func NewCompiler() *Compiler {
 // Compile transforms a Workflow into a Petri net
 func (c *Compiler) Compile(wf *Workflow) (*petrinet.PetriNet, error) {
 	net := petrinet.NewPetriNet(wf.Name)
+	net.Conflict = conflictPolicy(wf.Conflict)
 
 	// Step 1: Create places for resources
 	for _, resource := range wf.Resources {
@@ -63,19 +64,31 @@ func (c *Compiler) Compile(wf *Workflow) (*petrinet.PetriNet, error) {
 			channel.ID,
 			channel.Capacity,
 		)
+		place.Ordering = channelOrdering(channel.Type)
 		net.AddPlace(place)
 	}
 
 	// Step 4: Create transitions for tasks
 	for _, task := range wf.Tasks {
+		if task.Subworkflow != "" {
+			if err := c.compileSubworkflow(wf, task, net); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		transition := c.compileTask(task)
 		net.AddTransition(transition)
 
-		// Connect context place if specified (consumed and re-emitted).
+		// Connect context place if specified. Readers share the context token through
+		// a read arc; writers consume and re-emit it, which makes them exclusive.
 		if task.Context != "" {
 			if ctxPlace, ok := net.Places[task.Context]; ok {
-				transition.AddInputArc(ctxPlace, 1)
-				transition.AddOutputArc(ctxPlace, 1)
+				if task.ContextMode == "write" {
+					transition.AddInputArc(ctxPlace, 1)
+					transition.AddOutputArc(ctxPlace, 1)
+				} else {
+					transition.AddReadArc(ctxPlace, 1)
+				}
 			} else {
 				return nil, fmt.Errorf("task %s references missing context place %s", task.ID, task.Context)
 			}
@@ -97,6 +110,22 @@ func (c *Compiler) Compile(wf *Workflow) (*petrinet.PetriNet, error) {
 			}
 		}
 
+		// Connect inhibitor arcs (task is enabled only while the place is below the threshold)
+		for placeID, threshold := range task.InhibitedBy {
+			if place, exists := net.Places[placeID]; exists {
+				transition.AddInhibitorArc(place, threshold)
+			}
+		}
+
+		// Connect reset arcs for cancellation (channel emptied when the task fires)
+		for _, channelID := range task.Cancels {
+			place, ok := net.Places[channelID]
+			if !ok {
+				return nil, fmt.Errorf("task %s cancels missing channel place %s", task.ID, channelID)
+			}
+			transition.AddResetArc(place)
+		}
+
 		// Connect output channels
 		if task.Output != "" {
 			transition.AddOutputArc(net.Places[task.Output], 1)
@@ -123,19 +152,66 @@ func (c *Compiler) Compile(wf *Workflow) (*petrinet.PetriNet, error) {
 	return net, nil
 }
 
+// CompileLinked compiles workflows that exchange tokens through fused channels
+// into one net each. Channels with the same Fusion name become a single place
+// shared by the nets, known in each of them under the fusion name, so the nets
+// can run concurrently: tokens produced by one enable tasks of the others.
+// Channels in a fusion set must agree on capacity and type.
+func (c *Compiler) CompileLinked(wfs ...*Workflow) ([]*petrinet.PetriNet, error) {
+	nets := make([]*petrinet.PetriNet, len(wfs))
+	sets := make(map[string]*petrinet.FusionSet)
+	first := make(map[string]Channel)
+	for i, wf := range wfs {
+		net, err := c.Compile(wf)
+		if err != nil {
+			return nil, fmt.Errorf("workflow %s: %w", wf.Name, err)
+		}
+		nets[i] = net
+
+		for _, channel := range wf.Channels {
+			if channel.Fusion == "" {
+				continue
+			}
+			set, ok := sets[channel.Fusion]
+			if !ok {
+				set = petrinet.NewFusionSet(channel.Fusion, channel.Fusion, channel.Capacity)
+				set.Place.Ordering = channelOrdering(channel.Type)
+				sets[channel.Fusion] = set
+				first[channel.Fusion] = channel
+			} else if other := first[channel.Fusion]; other.Capacity != channel.Capacity || channelOrdering(other.Type) != channelOrdering(channel.Type) {
+				return nil, fmt.Errorf("workflow %s: channel %s does not match the capacity and type of fusion set %s", wf.Name, channel.ID, channel.Fusion)
+			}
+			if err := set.Join(net, channel.ID); err != nil {
+				return nil, fmt.Errorf("workflow %s: %w", wf.Name, err)
+			}
+		}
+	}
+	return nets, nil
+}
+
 // compileTask converts a Task to a Petri net Transition
 func (c *Compiler) compileTask(task Task) *petrinet.Transition {
 	transition := petrinet.NewTransition(task.ID, task.ID)
+	transition.Priority = task.Priority
 
 	// Wrap task action to handle Petri net token inputs/outputs
 	transition.Action = func(ctx context.Context, tokens []*petrinet.Token) ([]*petrinet.Token, error) {
+		// A context read through a read arc comes after the consumed tokens.
+		// Move it to the front, where its input arc puts it in write mode, so
+		// the action gets the same input in both modes.
+		if task.Context != "" && task.ContextMode != "write" && len(tokens) > 0 {
+			tokens = append([]*petrinet.Token{tokens[len(tokens)-1]}, tokens[:len(tokens)-1]...)
+		}
+
 		// Extract input data from tokens
 		var inputData interface{}
+		priority := 0
 		if len(tokens) > 0 {
 			// Skip resource tokens, find data token
 			for _, token := range tokens {
 				if token.Data != nil {
 					inputData = token.Data
+					priority = token.Priority
 					break
 				}
 			}
@@ -175,8 +251,9 @@ func (c *Compiler) compileTask(task Task) *petrinet.Transition {
 		// Add data output token
 		if outputData != nil {
 			outputTokens = append(outputTokens, &petrinet.Token{
-				ID:   fmt.Sprintf("%s-output", task.ID),
-				Data: outputData,
+				ID:       fmt.Sprintf("%s-output", task.ID),
+				Data:     outputData,
+				Priority: priority, // Carry the input's priority downstream
 			})
 		}
 
@@ -186,11 +263,96 @@ func (c *Compiler) compileTask(task Task) *petrinet.Transition {
 	return transition
 }
 
+// compileSubworkflow embeds the sub-workflow of task: a substitution
+// transition connected to the task's channels is refined by the compiled
+// sub-workflow, whose port channels are fused with those channels.
+func (c *Compiler) compileSubworkflow(wf *Workflow, task Task, net *petrinet.PetriNet) error {
+	sub := wf.subworkflow(task.Subworkflow)
+	if sub == nil {
+		return fmt.Errorf("task %s embeds missing subworkflow %s", task.ID, task.Subworkflow)
+	}
+	child, err := c.Compile(sub.Workflow)
+	if err != nil {
+		return fmt.Errorf("subworkflow %s: %w", sub.ID, err)
+	}
+	inputs, outputs := task.inputs(), task.outputs()
+	if len(inputs) != len(sub.Inputs) || len(outputs) != len(sub.Outputs) {
+		return fmt.Errorf("task %s does not match the ports of subworkflow %s", task.ID, sub.ID)
+	}
+
+	transition := petrinet.NewTransition(task.ID, task.ID)
+	ports := make(map[string]string)
+	for i, channelID := range inputs {
+		transition.AddInputArc(net.Places[channelID], 1)
+		ports[sub.Inputs[i]] = channelID
+	}
+	for i, channelID := range outputs {
+		transition.AddOutputArc(net.Places[channelID], 1)
+		ports[sub.Outputs[i]] = channelID
+	}
+
+	// Resources and contexts declared on both sides are shared. Their tokens
+	// are those of the embedding workflow, so the child's copies are dropped.
+	for _, r := range sub.Workflow.Resources {
+		for _, shared := range wf.Resources {
+			if shared.ID == r.ID {
+				ports[r.ID] = r.ID
+			}
+		}
+	}
+	for _, ctx := range sub.Workflow.Contexts {
+		for _, shared := range wf.Contexts {
+			if shared.ID == ctx.ID {
+				ports[ctx.ID] = ctx.ID
+			}
+		}
+	}
+	for portID := range ports {
+		if place, ok := child.Places[portID]; ok {
+			if _, err := place.RemoveTokens(place.TokenCount()); err != nil {
+				return err
+			}
+		}
+	}
+
+	net.AddTransition(transition)
+	return net.Refine(transition, child, ports)
+}
+
+// channelOrdering maps a channel type onto the queue discipline of its place.
+func channelOrdering(channelType string) petrinet.Ordering {
+	switch channelType {
+	case "lifo":
+		return petrinet.LIFO
+	case "priority":
+		return petrinet.ByPriority
+	default:
+		return petrinet.FIFO
+	}
+}
+
+// conflictPolicy maps a workflow's conflict setting onto a scheduler policy.
+func conflictPolicy(name string) petrinet.ConflictPolicy {
+	switch name {
+	case "priority":
+		return petrinet.StaticPriority
+	case "weighted":
+		return petrinet.WeightedRandom
+	case "round_robin":
+		return petrinet.NewRoundRobin()
+	case "least_recent":
+		return petrinet.NewLeastRecentlyFired()
+	default:
+		return nil
+	}
+}
+
 // compileGateway converts a Gateway to Petri net structures
 func (c *Compiler) compileGateway(gateway Gateway, net *petrinet.PetriNet) error {
 	switch gateway.Type {
-	case "barrier":
-		// Barrier: Wait for all inputs before proceeding
+	case "barrier", "cancel":
+		// Barrier: Wait for all inputs before proceeding. A cancel gateway is a
+		// barrier whose firing also empties the channels listed in Cancels.
 		waitFor := gateway.Inputs
 		if len(waitFor) == 0 {
 			waitFor = gateway.WaitFor
@@ -220,6 +382,14 @@ func (c *Compiler) compileGateway(gateway Gateway, net *petrinet.PetriNet) error
 			barrierTransition.AddInputArc(signalPlace, 1)
 		}
 
+		for _, channelID := range gateway.Cancels {
+			place, ok := net.Places[channelID]
+			if !ok {
+				return fmt.Errorf("gateway %s cancels missing channel place %s", gateway.ID, channelID)
+			}
+			barrierTransition.AddResetArc(place)
+		}
+
 		barrierTransition.AddOutputArc(barrierPlace, 1)
 		net.AddTransition(barrierTransition)
 	}
@@ -0,0 +1,153 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"petri-net-mvp/core/petrinet"
+)
+
+func TestContextIsActionInputInBothModes(t *testing.T) {
+	for _, mode := range []string{"", "read", "write"} {
+		t.Run("mode="+mode, func(t *testing.T) {
+			var input interface{}
+			wf := &Workflow{
+				Name:     "context",
+				Contexts: []Context{{ID: "ctx", Capacity: 1}},
+				Channels: []Channel{{ID: "docs", Capacity: -1}, {ID: "out", Capacity: -1}},
+				Tasks: []Task{{
+					ID:          "summarize",
+					Input:       "docs",
+					Output:      "out",
+					Context:     "ctx",
+					ContextMode: mode,
+					Action: func(ctx context.Context, in interface{}) (interface{}, error) {
+						input = in
+						return "summary", nil
+					},
+				}},
+			}
+			net, err := NewCompiler().Compile(wf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			net.Places["docs"].AddTokens(&petrinet.Token{ID: "doc", Data: "document"})
+			if _, err := net.Run(context.Background(), petrinet.RunOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := input.(map[string]interface{}); !ok {
+				t.Errorf("action input = %#v, want the context map", input)
+			}
+			if n := net.Places["ctx"].TokenCount(); n != 1 {
+				t.Errorf("context place holds %d tokens, want 1", n)
+			}
+		})
+	}
+}
+
+func TestSubworkflowSharesResourceTokens(t *testing.T) {
+	review := &Workflow{
+		Name:      "review",
+		Resources: []Resource{{ID: "api_tokens", Type: "semaphore", Capacity: 2}},
+		Channels:  []Channel{{ID: "draft", Capacity: -1}, {ID: "approved", Capacity: -1}},
+		Tasks: []Task{{
+			ID:       "check",
+			Input:    "draft",
+			Output:   "approved",
+			Requires: map[string]int{"api_tokens": 1},
+		}},
+	}
+	wf := &Workflow{
+		Name:         "publishing",
+		Resources:    []Resource{{ID: "api_tokens", Type: "semaphore", Capacity: 3}},
+		Channels:     []Channel{{ID: "drafts", Capacity: -1}, {ID: "checked", Capacity: -1}, {ID: "final", Capacity: -1}},
+		Subworkflows: []Subworkflow{{ID: "review", Inputs: []string{"draft"}, Outputs: []string{"approved"}, Workflow: review}},
+		Tasks: []Task{
+			{ID: "first", Subworkflow: "review", Input: "drafts", Output: "checked"},
+			{ID: "second", Subworkflow: "review", Input: "checked", Output: "final"},
+		},
+	}
+	net, err := NewCompiler().Compile(wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := net.Places["api_tokens"].TokenCount(); n != 3 {
+		t.Errorf("api_tokens holds %d tokens, want the embedding workflow's 3", n)
+	}
+	net.Places["drafts"].AddTokens(&petrinet.Token{ID: "draft", Data: "text"})
+	if _, err := net.Run(context.Background(), petrinet.RunOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := net.Places["final"].TokenCount(); n != 1 {
+		t.Errorf("final holds %d tokens, want 1", n)
+	}
+}
+
+func TestCompileCancels(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    []string // channels cancelled by the task
+		gateway []string // channels cancelled by the gateway
+		wantErr string
+	}{
+		{"known channel", []string{"pending"}, []string{"pending"}, ""},
+		{"task cancels missing channel", []string{"nowhere"}, nil, "task work cancels missing channel place nowhere"},
+		{"gateway cancels missing channel", nil, []string{"nowhere"}, "gateway stop cancels missing channel place nowhere"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &Workflow{
+				Name:     "cancel",
+				Channels: []Channel{{ID: "jobs", Capacity: -1}, {ID: "pending", Capacity: -1}},
+				Tasks:    []Task{{ID: "work", Input: "jobs", Cancels: tt.task}},
+				Gateways: []Gateway{{ID: "stop", Type: "cancel", WaitFor: []string{"work"}, Cancels: tt.gateway}},
+			}
+			net, err := NewCompiler().Compile(wf)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Compile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			net.Places["jobs"].AddTokens(&petrinet.Token{ID: "job"})
+			net.Places["pending"].AddTokens(&petrinet.Token{ID: "a"}, &petrinet.Token{ID: "b"})
+			if _, err := net.Run(context.Background(), petrinet.RunOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if n := net.Places["pending"].TokenCount(); n != 0 {
+				t.Errorf("pending holds %d tokens after cancellation, want 0", n)
+			}
+		})
+	}
+}
+
+func TestChannelTypes(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want petrinet.Ordering
+	}{
+		{"", petrinet.FIFO},
+		{"fifo", petrinet.FIFO},
+		{"lifo", petrinet.LIFO},
+		{"priority", petrinet.ByPriority},
+	}
+	for _, tt := range tests {
+		t.Run("type="+tt.typ, func(t *testing.T) {
+			wf := &Workflow{
+				Name:     "channels",
+				Channels: []Channel{{ID: "queue", Capacity: -1, Type: tt.typ}},
+				Tasks:    []Task{{ID: "drain", Input: "queue"}},
+			}
+			net, err := NewCompiler().Compile(wf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := net.Places["queue"].Ordering; got != tt.want {
+				t.Errorf("ordering = %T, want %T", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package workflow
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckSiphons(t *testing.T) {
+	tests := []struct {
+		name string
+		wf   *Workflow
+		want string
+	}{
+		{"pipeline", &Workflow{
+			Channels: []Channel{{ID: "docs", Capacity: -1}, {ID: "out", Capacity: -1}},
+			Tasks: []Task{
+				{ID: "load", Output: "docs"},
+				{ID: "process", Input: "docs", Output: "out"},
+			},
+		}, "[]"},
+		{"input nobody fills", &Workflow{
+			Channels: []Channel{{ID: "docs", Capacity: -1}, {ID: "approvals", Capacity: -1}, {ID: "out", Capacity: -1}},
+			Tasks: []Task{
+				{ID: "load", Output: "docs"},
+				{ID: "publish", Inputs: []string{"docs", "approvals"}, Output: "out"},
+			},
+		}, "[{channel approvals} can be emptied and stay empty (no trap)]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.wf.Name = tt.name
+			warnings, err := CheckSiphons(tt.wf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(warnings); got != tt.want {
+				t.Errorf("CheckSiphons() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
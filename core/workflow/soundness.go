@@ -0,0 +1,315 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"petri-net-mvp/core/analysis"
+	"petri-net-mvp/core/petrinet"
+)
+
+// Names of the places and transitions added to turn a compiled workflow into a
+// workflow net (WF-net) with a single source place and a single sink place.
+const (
+	wfSource     = "_i"
+	wfSink       = "_o"
+	wfStart      = "_start"
+	wfEnd        = "_end"
+	wfEntry      = "_pre_"
+	soundnessMax = 10000
+)
+
+// ErrSoundnessUndecided is returned by CheckSoundness when the workflow net has
+// too many reachable markings to explore but no place was found unbounded.
+var ErrSoundnessUndecided = errors.New("soundness undecided")
+
+// SoundnessError reports a soundness violation together with a firing sequence
+// of the workflow net that exhibits it.
+type SoundnessError struct {
+	Property string // "single sink", "bounded", "option to complete", "proper completion" or "no dead transitions"
+	Detail   string
+	Trace    []string // Firing sequence from the initial marking; empty when not applicable
+}
+
+func (e *SoundnessError) Error() string {
+	msg := fmt.Sprintf("workflow is not sound (%s): %s", e.Property, e.Detail)
+	if len(e.Trace) > 0 {
+		msg += "\n  trace: " + strings.Join(e.Trace, " → ")
+	}
+	return msg
+}
+
+// CheckSoundness verifies that wf compiles to a sound workflow net: every case
+// started in the source place can complete, completion leaves no tokens behind
+// (resources and contexts return to their initial marking), and every task and
+// gateway can fire. Tasks without inputs are started once per case, completion
+// is reached once every sink has been marked, and _done places that nothing
+// consumes are bookkeeping and ignored. Workflows with more than 10000
+// reachable markings and no unbounded place give ErrSoundnessUndecided.
+func CheckSoundness(wf *Workflow) error {
+	net, err := NewCompiler().Compile(wf)
+	if err != nil {
+		return err
+	}
+
+	static := make(map[string]bool)
+	staticPlaces(wf, "", net, static)
+	initial := make(map[string]int)
+	for id := range static {
+		initial[id] = net.Places[id].TokenCount()
+	}
+
+	if err := toWorkflowNet(wf, net); err != nil {
+		return err
+	}
+	graph, err := analysis.Reachability(net, analysis.Options{MaxStates: soundnessMax})
+	if err != nil {
+		return err
+	}
+	if !graph.Complete {
+		// Large but bounded workflows are not unsound; only a pumping witness
+		// from the coverability tree proves a violation
+		report, err := analysis.Check(net, analysis.CheckOptions{Options: analysis.Options{MaxStates: soundnessMax}})
+		if err != nil {
+			return err
+		}
+		for _, f := range report.Findings {
+			if f.Kind == analysis.UnboundedPlace {
+				return &SoundnessError{
+					Property: "bounded",
+					Detail:   fmt.Sprintf("place %s is unbounded", f.Place),
+					Trace:    f.Witness,
+				}
+			}
+		}
+		return fmt.Errorf("%w: more than %d reachable markings", ErrSoundnessUndecided, soundnessMax)
+	}
+
+	// Proper completion: the sink is marked once and nothing else is left over
+	var completed []int
+	for _, s := range graph.States {
+		counts := graph.Counts(s.ID)
+		if counts[wfSink] == 0 {
+			continue
+		}
+		completed = append(completed, s.ID)
+		var leftover []string
+		for id, n := range counts {
+			if id == wfSink && n == 1 || static[id] && n == initial[id] {
+				continue
+			}
+			leftover = append(leftover, fmt.Sprintf("%s:%d", id, n))
+		}
+		for id, n := range initial {
+			if _, ok := counts[id]; !ok && n != 0 {
+				leftover = append(leftover, fmt.Sprintf("%s:0", id))
+			}
+		}
+		if len(leftover) > 0 {
+			sort.Strings(leftover)
+			return &SoundnessError{
+				Property: "proper completion",
+				Detail:   fmt.Sprintf("completed with %s", strings.Join(leftover, ", ")),
+				Trace:    graph.Path(s.ID),
+			}
+		}
+	}
+
+	// Option to complete: every reachable marking can still reach completion
+	predecessors := make([][]int, len(graph.States))
+	for _, s := range graph.States {
+		for _, e := range s.Edges {
+			predecessors[e.To] = append(predecessors[e.To], s.ID)
+		}
+	}
+	canComplete := make([]bool, len(graph.States))
+	queue := completed
+	for _, id := range queue {
+		canComplete[id] = true
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, pred := range predecessors[id] {
+			if !canComplete[pred] {
+				canComplete[pred] = true
+				queue = append(queue, pred)
+			}
+		}
+	}
+	stuck := -1
+	for _, s := range graph.States {
+		if canComplete[s.ID] {
+			continue
+		}
+		// Prefer a dead marking as the counterexample: it shows where the case stops
+		if stuck < 0 || len(s.Edges) == 0 && len(graph.States[stuck].Edges) > 0 {
+			stuck = s.ID
+		}
+	}
+	if stuck >= 0 {
+		return &SoundnessError{
+			Property: "option to complete",
+			Detail:   fmt.Sprintf("marking %s can no longer complete", graph.Format(stuck)),
+			Trace:    graph.Path(stuck),
+		}
+	}
+
+	// No dead transitions
+	fired := make(map[string]bool)
+	for _, s := range graph.States {
+		for _, e := range s.Edges {
+			fired[e.Transition] = true
+		}
+	}
+	for _, id := range graph.Transitions {
+		if !fired[id] {
+			return &SoundnessError{Property: "no dead transitions", Detail: fmt.Sprintf("%s can never fire", id)}
+		}
+	}
+	return nil
+}
+
+// toWorkflowNet adds a source place feeding every task without inputs and a
+// sink place filled once every terminal place is marked: channels nobody
+// consumes, completion places of tasks without outputs and gateway completion
+// places. Other _done places that nothing consumes are removed. Only input
+// arcs consume: a channel that is merely read, tested by an inhibitor or
+// cleared by a cancellation is still terminal. Fused channels that nothing in
+// wf produces are fed by another workflow, so the case starts with a token in
+// them.
+func toWorkflowNet(wf *Workflow, net *petrinet.PetriNet) error {
+	consumed := make(map[*petrinet.Place]bool)
+	produced := make(map[*petrinet.Place]bool)
+	for _, t := range net.Transitions {
+		for _, a := range t.InputArcs {
+			consumed[a.Place] = true
+		}
+		for _, a := range t.OutputArcs {
+			produced[a.Place] = true
+		}
+	}
+
+	var sinks []*petrinet.Place
+	ignored := make(map[*petrinet.Place]bool)
+	for _, c := range wf.Channels {
+		if place := net.Places[c.ID]; !consumed[place] {
+			sinks = append(sinks, place)
+		}
+	}
+	for _, task := range wf.Tasks {
+		if task.Subworkflow != "" {
+			continue
+		}
+		place := net.Places[task.ID+"_done"]
+		switch {
+		case consumed[place]:
+		case task.Output == "" && len(task.Outputs) == 0:
+			sinks = append(sinks, place)
+		default:
+			ignored[place] = true
+		}
+	}
+	for _, g := range wf.Gateways {
+		if place, ok := net.Places[g.ID+"_complete"]; ok && !consumed[place] {
+			sinks = append(sinks, place)
+		}
+	}
+
+	ignoreSubworkflowSignals(wf, "", net, consumed, ignored)
+
+	if len(sinks) == 0 {
+		return &SoundnessError{Property: "single sink", Detail: "every channel and task output is consumed again, so no case can ever end"}
+	}
+
+	for place := range ignored {
+		delete(net.Places, place.ID)
+	}
+	for _, t := range net.Transitions {
+		kept := t.OutputArcs[:0]
+		for _, a := range t.OutputArcs {
+			if !ignored[a.Place] {
+				kept = append(kept, a)
+			}
+		}
+		t.OutputArcs = kept
+	}
+
+	source := petrinet.NewPlace(wfSource, "Workflow Start", 1)
+	source.AddTokens(&petrinet.Token{ID: "case"})
+	net.AddPlace(source)
+	start := petrinet.NewTransition(wfStart, "Start Case")
+	start.AddInputArc(source, 1)
+	net.AddTransition(start)
+	for _, task := range wf.Tasks {
+		if task.Input != "" || len(task.Inputs) > 0 || task.Subworkflow != "" {
+			continue
+		}
+		entry := petrinet.NewPlace(wfEntry+task.ID, task.ID+" Entry", 1)
+		net.AddPlace(entry)
+		start.AddOutputArc(entry, 1)
+		net.Transitions[task.ID].AddInputArc(entry, 1)
+	}
+	for _, c := range wf.Channels {
+		if place := net.Places[c.ID]; c.Fusion != "" && !produced[place] {
+			start.AddOutputArc(place, 1)
+		}
+	}
+
+	sink := petrinet.NewPlace(wfSink, "Workflow End", -1)
+	net.AddPlace(sink)
+	end := petrinet.NewTransition(wfEnd, "End Case")
+	for _, place := range sinks {
+		end.AddInputArc(place, 1)
+	}
+	end.AddOutputArc(sink, 1)
+	net.AddTransition(end)
+	return nil
+}
+
+// ignoreSubworkflowSignals marks the unconsumed completion places inside the
+// sub-workflows embedded by wf's tasks: the case ends in the embedding
+// workflow, so they only accumulate tokens.
+func ignoreSubworkflowSignals(wf *Workflow, prefix string, net *petrinet.PetriNet, consumed, ignored map[*petrinet.Place]bool) {
+	for _, task := range wf.Tasks {
+		sub := wf.subworkflow(task.Subworkflow)
+		if sub == nil {
+			continue
+		}
+		inner := prefix + task.ID + "/"
+		for _, t := range sub.Workflow.Tasks {
+			if place, ok := net.Places[inner+t.ID+"_done"]; ok && !consumed[place] {
+				ignored[place] = true
+			}
+		}
+		for _, g := range sub.Workflow.Gateways {
+			if place, ok := net.Places[inner+g.ID+"_complete"]; ok && !consumed[place] {
+				ignored[place] = true
+			}
+		}
+		ignoreSubworkflowSignals(sub.Workflow, inner, net, consumed, ignored)
+	}
+}
+
+// staticPlaces collects the resource and context places of wf and of the
+// sub-workflows it embeds, which must be back to their initial marking when
+// a case completes. Sub-workflow places shared with wf appear once.
+func staticPlaces(wf *Workflow, prefix string, net *petrinet.PetriNet, static map[string]bool) {
+	for _, r := range wf.Resources {
+		if _, ok := net.Places[prefix+r.ID]; ok {
+			static[prefix+r.ID] = true
+		}
+	}
+	for _, c := range wf.Contexts {
+		if _, ok := net.Places[prefix+c.ID]; ok {
+			static[prefix+c.ID] = true
+		}
+	}
+	for _, task := range wf.Tasks {
+		if sub := wf.subworkflow(task.Subworkflow); sub != nil {
+			staticPlaces(sub.Workflow, prefix+task.ID+"/", net, static)
+		}
+	}
+}
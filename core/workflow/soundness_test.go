@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCheckSoundness(t *testing.T) {
+	tests := []struct {
+		name         string
+		wf           *Workflow
+		wantProperty string // "" for sound
+		wantErr      error
+	}{
+		{"pipeline", &Workflow{
+			Channels: []Channel{Channel{ID: "docs", Capacity: -1}, Channel{ID: "out", Capacity: -1}},
+			Tasks: []Task{
+				{ID: "load", Output: "docs"},
+				{ID: "process", Input: "docs", Output: "out"},
+			},
+		}, "", nil},
+		{"choice leaving a branch unfinished", &Workflow{
+			Channels: []Channel{Channel{ID: "a", Capacity: -1}, Channel{ID: "b", Capacity: -1}, Channel{ID: "c", Capacity: -1}},
+			Tasks: []Task{
+				{ID: "src", Output: "a"},
+				{ID: "left", Input: "a", Output: "b"},
+				{ID: "right", Input: "a", Output: "c"},
+				{ID: "join", Inputs: []string{"b", "c"}},
+			},
+		}, "option to complete", nil},
+		{"producer loop", &Workflow{
+			Channels: []Channel{Channel{ID: "ticks", Capacity: -1}, Channel{ID: "spam", Capacity: -1}},
+			Tasks: []Task{
+				{ID: "start", Output: "ticks"},
+				{ID: "tick", Input: "ticks", Outputs: []string{"ticks", "spam"}},
+			},
+		}, "bounded", nil},
+		{"large but bounded", parallelTasks(14), "", ErrSoundnessUndecided},
+		{"terminal channel tested by an inhibitor", &Workflow{
+			Channels: []Channel{Channel{ID: "docs", Capacity: -1}, Channel{ID: "out", Capacity: -1}, Channel{ID: "alerts", Capacity: -1}},
+			Tasks: []Task{
+				{ID: "load", Output: "docs"},
+				{ID: "flag", Output: "alerts"},
+				{ID: "process", Input: "docs", Output: "out", InhibitedBy: map[string]int{"alerts": 2}},
+			},
+		}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.wf.Name = tt.name
+			err := CheckSoundness(tt.wf)
+			var soundness *SoundnessError
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CheckSoundness() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantProperty == "":
+				if err != nil {
+					t.Fatalf("CheckSoundness() error = %v", err)
+				}
+			case !errors.As(err, &soundness):
+				t.Fatalf("CheckSoundness() error = %v, want a SoundnessError", err)
+			case soundness.Property != tt.wantProperty:
+				t.Errorf("violated %q, want %q (%v)", soundness.Property, tt.wantProperty, err)
+			}
+		})
+	}
+}
+
+// parallelTasks builds n independent tasks, whose interleavings give 2^n
+// reachable markings.
+func parallelTasks(n int) *Workflow {
+	wf := &Workflow{}
+	for i := 0; i < n; i++ {
+		out := fmt.Sprintf("out_%d", i)
+		wf.Channels = append(wf.Channels, Channel{ID: out, Capacity: -1})
+		wf.Tasks = append(wf.Tasks, Task{ID: fmt.Sprintf("task_%d", i), Output: out})
+	}
+	return wf
+}
@@ -4,12 +4,14 @@ import "context"
 
 // Workflow represents a high-level workflow definition
 type Workflow struct {
-	Name      string
-	Resources []Resource
-	Contexts  []Context
-	Channels  []Channel
-	Tasks     []Task
-	Gateways  []Gateway
+	Name         string
+	Resources    []Resource
+	Contexts     []Context
+	Channels     []Channel
+	Tasks        []Task
+	Gateways     []Gateway
+	Subworkflows []Subworkflow
+	Conflict     string // Conflict policy between competing tasks: "priority", "weighted", "round_robin", "least_recent" or "" (scheduler default)
 }
 
 // Resource represents a shared resource with capacity
@@ -31,21 +33,64 @@ type Channel struct {
 	ID       string
 	Capacity int    // -1 = unlimited
 	Type     string // "fifo", "lifo", "priority"
+	Fusion   string // Fusion set shared with channels of other workflows; see Compiler.CompileLinked
 }
 
 // Task represents a unit of work
 type Task struct {
+	ID          string
+	Type        string
+	Input       string         // Channel ID
+	Output      string         // Channel ID
+	Inputs      []string       // Multiple inputs
+	Outputs     []string       // Multiple outputs
+	Requires    map[string]int // Resource requirements: resource_id -> amount
+	InhibitedBy map[string]int // Inhibitor arcs: place_id -> threshold (enabled while place holds fewer tokens)
+	Cancels     []string       // Channel IDs emptied when the task fires (reset arcs)
+	Parallel    bool           // Auto-spawn workers
+	Context     string         // Optional context place ID
+	ContextMode string         // "read" (default) or "write"; the context is the action's input either way
+	Priority    int            // Preference when competing tasks are enabled; see Workflow.Conflict
+	Subworkflow string         // Sub-workflow embedded in place of an action; see Subworkflow
+	Action      TaskAction
+	Config      map[string]interface{}
+}
+
+// inputs returns the input channels of the task in order: Input, then Inputs.
+func (t Task) inputs() []string {
+	if t.Input == "" {
+		return t.Inputs
+	}
+	return append([]string{t.Input}, t.Inputs...)
+}
+
+// outputs returns the output channels of the task in order: Output, then Outputs.
+func (t Task) outputs() []string {
+	if t.Output == "" {
+		return t.Outputs
+	}
+	return append([]string{t.Output}, t.Outputs...)
+}
+
+// Subworkflow is a reusable workflow that tasks embed by ID. Each embedding
+// task is replaced by a copy of its tasks and channels; the port channels are
+// fused with the task's channels, and resources and contexts with the same ID
+// as one of the embedding workflow are shared with it.
+type Subworkflow struct {
 	ID       string
-	Type     string
-	Input    string         // Channel ID
-	Output   string         // Channel ID
-	Inputs   []string       // Multiple inputs
-	Outputs  []string       // Multiple outputs
-	Requires map[string]int // Resource requirements: resource_id -> amount
-	Parallel bool           // Auto-spawn workers
-	Context  string         // Optional context place ID
-	Action   TaskAction
-	Config   map[string]interface{}
+	Inputs   []string // Port channels fed by the embedding task's inputs, in order
+	Outputs  []string // Port channels feeding the embedding task's outputs, in order
+	Workflow *Workflow
+}
+
+// subworkflow returns the sub-workflow with the given ID, or nil.
+func (wf *Workflow) subworkflow(id string) *Subworkflow {
+	for i := range wf.Subworkflows {
+		if wf.Subworkflows[i].ID == id {
+			return &wf.Subworkflows[i]
+		}
+	}
+	return nil
 }
 
 // TaskAction is the function executed by a task
@@ -54,8 +99,9 @@ type TaskAction func(ctx context.Context, input interface{}) (interface{}, error
 // Gateway represents control flow (barrier, split, merge)
 type Gateway struct {
 	ID      string
-	Type    string   // "barrier", "split", "merge"
+	Type    string   // "barrier", "cancel", "split", "merge"
 	Inputs  []string // Task IDs to wait for
 	Outputs []string // Task IDs to trigger
 	WaitFor []string // Alias for Inputs
+	Cancels []string // Channel IDs emptied when the gateway fires (reset arcs)
 }
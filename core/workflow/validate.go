@@ -1,14 +1,41 @@
 package workflow
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
-// Validate ensures workflow definitions are internally consistent before compilation.
+// Validate ensures workflow definitions are internally consistent before
+// compilation: it runs ValidateStructure and then CheckSoundness. Workflows
+// too large to decide soundness for pass; use ValidateStructure alone to skip
+// the state-space exploration.
 func Validate(wf *Workflow) error {
+	if err := ValidateStructure(wf); err != nil {
+		return err
+	}
+	if err := CheckSoundness(wf); err != nil && !errors.Is(err, ErrSoundnessUndecided) {
+		return err
+	}
+	return nil
+}
+
+// ValidateStructure checks references between workflow elements without
+// exploring the state space. Sub-workflows are checked the same way but not
+// for soundness on their own: they are part of the net of every workflow
+// embedding them.
+func ValidateStructure(wf *Workflow) error {
 	resourceIDs := make(map[string]struct{})
 	contextIDs := make(map[string]struct{})
 	channelIDs := make(map[string]struct{})
 	taskIDs := make(map[string]struct{})
 	gatewayIDs := make(map[string]struct{})
+	fusionSets := make(map[string]string)
+
+	switch wf.Conflict {
+	case "", "priority", "weighted", "round_robin", "least_recent":
+	default:
+		return fmt.Errorf("unknown conflict policy %q (want priority, weighted, round_robin or least_recent)", wf.Conflict)
+	}
 
 	for _, r := range wf.Resources {
 		if r.ID == "" {
@@ -27,6 +54,17 @@ func Validate(wf *Workflow) error {
 		if _, exists := channelIDs[c.ID]; exists {
 			return fmt.Errorf("duplicate channel id: %s", c.ID)
 		}
+		switch c.Type {
+		case "", "fifo", "lifo", "priority":
+		default:
+			return fmt.Errorf("channel %s has unknown type %q (want fifo, lifo or priority)", c.ID, c.Type)
+		}
+		if c.Fusion != "" {
+			if other, exists := fusionSets[c.Fusion]; exists {
+				return fmt.Errorf("channels %s and %s are both in fusion set %s", other, c.ID, c.Fusion)
+			}
+			fusionSets[c.Fusion] = c.ID
+		}
 		channelIDs[c.ID] = struct{}{}
 	}
 
@@ -46,6 +84,40 @@ func Validate(wf *Workflow) error {
 		contextIDs[c.ID] = struct{}{}
 	}
 
+	subworkflowIDs := make(map[string]struct{})
+	for _, s := range wf.Subworkflows {
+		if s.ID == "" {
+			return fmt.Errorf("subworkflow id cannot be empty")
+		}
+		if _, exists := subworkflowIDs[s.ID]; exists {
+			return fmt.Errorf("duplicate subworkflow id: %s", s.ID)
+		}
+		subworkflowIDs[s.ID] = struct{}{}
+		if s.Workflow == nil {
+			return fmt.Errorf("subworkflow %s has no definition", s.ID)
+		}
+		if err := ValidateStructure(s.Workflow); err != nil {
+			return fmt.Errorf("subworkflow %s: %w", s.ID, err)
+		}
+		for _, c := range s.Workflow.Channels {
+			if c.Fusion != "" {
+				return fmt.Errorf("subworkflow %s: channel %s cannot join a fusion set, only top-level channels can", s.ID, c.ID)
+			}
+		}
+		if len(s.Inputs) == 0 {
+			return fmt.Errorf("subworkflow %s needs at least one input port", s.ID)
+		}
+		for _, port := range append(append([]string(nil), s.Inputs...), s.Outputs...) {
+			found := false
+			for _, c := range s.Workflow.Channels {
+				found = found || c.ID == port
+			}
+			if !found {
+				return fmt.Errorf("subworkflow %s has port %s, which is not one of its channels", s.ID, port)
+			}
+		}
+	}
+
 	for _, t := range wf.Tasks {
 		if t.ID == "" {
 			return fmt.Errorf("task id cannot be empty")
@@ -85,6 +157,40 @@ func Validate(wf *Workflow) error {
 				return fmt.Errorf("task %s references missing context %s", t.ID, t.Context)
 			}
 		}
+		switch t.ContextMode {
+		case "", "read", "write":
+		default:
+			return fmt.Errorf("task %s has invalid context_mode %q (want read or write)", t.ID, t.ContextMode)
+		}
+		for _, ch := range t.Cancels {
+			if _, ok := channelIDs[ch]; !ok {
+				return fmt.Errorf("task %s cancels missing channel %s", t.ID, ch)
+			}
+		}
+		if t.Subworkflow != "" {
+			s := wf.subworkflow(t.Subworkflow)
+			if s == nil {
+				return fmt.Errorf("task %s embeds missing subworkflow %s", t.ID, t.Subworkflow)
+			}
+			if len(t.inputs()) != len(s.Inputs) || len(t.outputs()) != len(s.Outputs) {
+				return fmt.Errorf("task %s has %d inputs and %d outputs, subworkflow %s has %d and %d ports",
+					t.ID, len(t.inputs()), len(t.outputs()), s.ID, len(s.Inputs), len(s.Outputs))
+			}
+			if len(t.Requires) > 0 || t.Context != "" || len(t.InhibitedBy) > 0 || len(t.Cancels) > 0 {
+				return fmt.Errorf("task %s embeds subworkflow %s and cannot have requires, context, inhibited_by or cancels", t.ID, s.ID)
+			}
+		}
+		for placeID, threshold := range t.InhibitedBy {
+			_, isChannel := channelIDs[placeID]
+			_, isResource := resourceIDs[placeID]
+			_, isContext := contextIDs[placeID]
+			if !isChannel && !isResource && !isContext {
+				return fmt.Errorf("task %s is inhibited by missing place %s", t.ID, placeID)
+			}
+			if threshold < 1 {
+				return fmt.Errorf("task %s has inhibitor threshold %d for %s (must be at least 1)", t.ID, threshold, placeID)
+			}
+		}
 	}
 
 	for _, g := range wf.Gateways {
@@ -103,8 +209,17 @@ func Validate(wf *Workflow) error {
 			if _, ok := taskIDs[wait]; !ok {
 				return fmt.Errorf("gateway %s references missing task %s", g.ID, wait)
 			}
+			for _, t := range wf.Tasks {
+				if t.ID == wait && t.Subworkflow != "" {
+					return fmt.Errorf("gateway %s waits for task %s, which embeds a subworkflow and has no completion signal", g.ID, wait)
+				}
+			}
+		}
+		for _, ch := range g.Cancels {
+			if _, ok := channelIDs[ch]; !ok {
+				return fmt.Errorf("gateway %s cancels missing channel %s", g.ID, ch)
+			}
 		}
 	}
-
 	return nil
 }
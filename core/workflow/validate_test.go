@@ -0,0 +1,41 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateChecksSoundness(t *testing.T) {
+	choice := &Workflow{
+		Name:     "choice",
+		Channels: []Channel{{ID: "a", Capacity: -1}, {ID: "b", Capacity: -1}, {ID: "c", Capacity: -1}},
+		Tasks: []Task{
+			{ID: "src", Output: "a"},
+			{ID: "left", Input: "a", Output: "b"},
+			{ID: "right", Input: "a", Output: "c"},
+			{ID: "join", Inputs: []string{"b", "c"}},
+		},
+	}
+	tests := []struct {
+		name      string
+		wf        *Workflow
+		validate  func(*Workflow) error
+		wantSound bool // whether the soundness violation is reported
+	}{
+		{"unsound", choice, Validate, true},
+		{"unsound, structure only", choice, ValidateStructure, false},
+		{"undecided", parallelTasks(14), Validate, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(tt.wf)
+			var soundness *SoundnessError
+			if got := errors.As(err, &soundness); got != tt.wantSound {
+				t.Errorf("error = %v, want soundness error %v", err, tt.wantSound)
+			}
+			if !tt.wantSound && err != nil {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
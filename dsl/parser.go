@@ -3,6 +3,7 @@ package dsl
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"petri-net-mvp/core/workflow"
 
 	"gopkg.in/yaml.v3"
@@ -10,14 +11,29 @@ import (
 
 // WorkflowYAML represents the YAML structure
 type WorkflowYAML struct {
-	Workflow struct {
-		Name      string         `yaml:"name"`
-		Resources []ResourceYAML `yaml:"resources,omitempty"`
-		Contexts  []ContextYAML  `yaml:"contexts,omitempty"`
-		Channels  []ChannelYAML  `yaml:"channels,omitempty"`
-		Tasks     []TaskYAML     `yaml:"tasks"`
-		Gateways  []GatewayYAML  `yaml:"gateways,omitempty"`
-	} `yaml:"workflow"`
+	Workflow WorkflowSpecYAML `yaml:"workflow"`
+}
+
+// WorkflowSpecYAML is the body of a workflow, also used by inline sub-workflows
+type WorkflowSpecYAML struct {
+	Name         string            `yaml:"name"`
+	Resources    []ResourceYAML    `yaml:"resources,omitempty"`
+	Contexts     []ContextYAML     `yaml:"contexts,omitempty"`
+	Channels     []ChannelYAML     `yaml:"channels,omitempty"`
+	Tasks        []TaskYAML        `yaml:"tasks"`
+	Gateways     []GatewayYAML     `yaml:"gateways,omitempty"`
+	Subworkflows []SubworkflowYAML `yaml:"subworkflows,omitempty"`
+	Conflict     string            `yaml:"conflict,omitempty"`
+}
+
+// SubworkflowYAML defines a sub-workflow inline or loads it from File, a
+// workflow file resolved relative to the including file
+type SubworkflowYAML struct {
+	ID               string   `yaml:"id"`
+	File             string   `yaml:"file,omitempty"`
+	Inputs           []string `yaml:"inputs"`
+	Outputs          []string `yaml:"outputs,omitempty"`
+	WorkflowSpecYAML `yaml:",inline"`
 }
 
 type ResourceYAML struct {
@@ -36,19 +52,25 @@ type ChannelYAML struct {
 	ID       string `yaml:"id"`
 	Capacity int    `yaml:"capacity"`
 	Type     string `yaml:"type,omitempty"`
+	Fusion   string `yaml:"fusion,omitempty"`
 }
 
 type TaskYAML struct {
-	ID       string                 `yaml:"id"`
-	Type     string                 `yaml:"type"`
-	Input    string                 `yaml:"input,omitempty"`
-	Output   string                 `yaml:"output,omitempty"`
-	Inputs   []string               `yaml:"inputs,omitempty"`
-	Outputs  []string               `yaml:"outputs,omitempty"`
-	Requires map[string]int         `yaml:"requires,omitempty"`
-	Parallel bool                   `yaml:"parallel,omitempty"`
-	Context  string                 `yaml:"context,omitempty"`
-	Config   map[string]interface{} `yaml:"config,omitempty"`
+	ID          string                 `yaml:"id"`
+	Type        string                 `yaml:"type"`
+	Input       string                 `yaml:"input,omitempty"`
+	Output      string                 `yaml:"output,omitempty"`
+	Inputs      []string               `yaml:"inputs,omitempty"`
+	Outputs     []string               `yaml:"outputs,omitempty"`
+	Requires    map[string]int         `yaml:"requires,omitempty"`
+	InhibitedBy map[string]int         `yaml:"inhibited_by,omitempty"`
+	Cancels     []string               `yaml:"cancels,omitempty"`
+	Parallel    bool                   `yaml:"parallel,omitempty"`
+	Context     string                 `yaml:"context,omitempty"`
+	ContextMode string                 `yaml:"context_mode,omitempty"`
+	Priority    int                    `yaml:"priority,omitempty"`
+	Subworkflow string                 `yaml:"subworkflow,omitempty"`
+	Config      map[string]interface{} `yaml:"config,omitempty"`
 
 	// Task-specific fields
 	Model  string `yaml:"model,omitempty"`
@@ -65,10 +87,16 @@ type GatewayYAML struct {
 	Inputs  []string `yaml:"inputs,omitempty"`
 	Outputs []string `yaml:"outputs,omitempty"`
 	WaitFor []string `yaml:"wait_for,omitempty"`
+	Cancels []string `yaml:"cancels,omitempty"`
 }
 
 // Parser parses YAML workflow definitions
-type Parser struct{}
+type Parser struct {
+	// SkipSoundness makes parsing run only workflow.ValidateStructure instead
+	// of workflow.Validate, which also checks soundness by exploring the
+	// state space. Tools that report soundness themselves set it.
+	SkipSoundness bool
+}
 
 // NewParser creates a new DSL parser
 func NewParser() *Parser {
@@ -82,27 +110,83 @@ func (p *Parser) ParseFile(filename string) (*workflow.Workflow, error) {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	return p.Parse(data)
+	return p.parse(data, filename)
 }
 
-// Parse parses YAML workflow data
+// Parse parses YAML workflow data. Sub-workflow files are resolved relative
+// to the current directory.
 func (p *Parser) Parse(data []byte) (*workflow.Workflow, error) {
+	return p.parse(data, "")
+}
+
+func (p *Parser) parse(data []byte, filename string) (*workflow.Workflow, error) {
 	var wfYAML WorkflowYAML
 	if err := yaml.Unmarshal(data, &wfYAML); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	loading := make(map[string]bool)
+	if filename != "" {
+		if abs, err := filepath.Abs(filename); err == nil {
+			loading[abs] = true
+		}
+	}
+	wf, err := p.convert(&wfYAML.Workflow, filepath.Dir(filename), loading)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := workflow.Validate
+	if p.SkipSoundness {
+		validate = workflow.ValidateStructure
+	}
+	if err := validate(wf); err != nil {
+		return nil, fmt.Errorf("workflow validation failed: %w", err)
+	}
+
+	return wf, nil
+}
+
+// loadSubworkflow reads a sub-workflow file. loading holds the files being
+// loaded further up, to reject files that include themselves.
+func (p *Parser) loadSubworkflow(path string, loading map[string]bool) (*workflow.Workflow, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if loading[abs] {
+		return nil, fmt.Errorf("%s includes itself", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	var wfYAML WorkflowYAML
+	if err := yaml.Unmarshal(data, &wfYAML); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML in %s: %w", path, err)
+	}
+
+	loading[abs] = true
+	defer delete(loading, abs)
+	return p.convert(&wfYAML.Workflow, filepath.Dir(path), loading)
+}
+
+// convert builds a workflow from its YAML body; dir is the directory of the
+// file it came from.
+func (p *Parser) convert(spec *WorkflowSpecYAML, dir string, loading map[string]bool) (*workflow.Workflow, error) {
 	wf := &workflow.Workflow{
-		Name:      wfYAML.Workflow.Name,
-		Resources: make([]workflow.Resource, len(wfYAML.Workflow.Resources)),
-		Contexts:  make([]workflow.Context, len(wfYAML.Workflow.Contexts)),
-		Channels:  make([]workflow.Channel, len(wfYAML.Workflow.Channels)),
-		Tasks:     make([]workflow.Task, len(wfYAML.Workflow.Tasks)),
-		Gateways:  make([]workflow.Gateway, len(wfYAML.Workflow.Gateways)),
+		Name:         spec.Name,
+		Resources:    make([]workflow.Resource, len(spec.Resources)),
+		Contexts:     make([]workflow.Context, len(spec.Contexts)),
+		Channels:     make([]workflow.Channel, len(spec.Channels)),
+		Tasks:        make([]workflow.Task, len(spec.Tasks)),
+		Gateways:     make([]workflow.Gateway, len(spec.Gateways)),
+		Subworkflows: make([]workflow.Subworkflow, len(spec.Subworkflows)),
+		Conflict:     spec.Conflict,
 	}
 
 	// Convert resources
-	for i, r := range wfYAML.Workflow.Resources {
+	for i, r := range spec.Resources {
 		wf.Resources[i] = workflow.Resource{
 			ID:       r.ID,
 			Type:     r.Type,
@@ -111,7 +195,7 @@ func (p *Parser) Parse(data []byte) (*workflow.Workflow, error) {
 	}
 
 	// Convert contexts
-	for i, c := range wfYAML.Workflow.Contexts {
+	for i, c := range spec.Contexts {
 		capacity := c.Capacity
 		if capacity == 0 {
 			capacity = 1
@@ -124,27 +208,33 @@ func (p *Parser) Parse(data []byte) (*workflow.Workflow, error) {
 	}
 
 	// Convert channels
-	for i, c := range wfYAML.Workflow.Channels {
+	for i, c := range spec.Channels {
 		wf.Channels[i] = workflow.Channel{
 			ID:       c.ID,
 			Capacity: c.Capacity,
 			Type:     c.Type,
+			Fusion:   c.Fusion,
 		}
 	}
 
 	// Convert tasks
-	for i, t := range wfYAML.Workflow.Tasks {
+	for i, t := range spec.Tasks {
 		task := workflow.Task{
-			ID:       t.ID,
-			Type:     t.Type,
-			Input:    t.Input,
-			Output:   t.Output,
-			Inputs:   t.Inputs,
-			Outputs:  t.Outputs,
-			Requires: t.Requires,
-			Parallel: t.Parallel,
-			Context:  t.Context,
-			Config:   make(map[string]interface{}),
+			ID:          t.ID,
+			Type:        t.Type,
+			Input:       t.Input,
+			Output:      t.Output,
+			Inputs:      t.Inputs,
+			Outputs:     t.Outputs,
+			Requires:    t.Requires,
+			InhibitedBy: t.InhibitedBy,
+			Cancels:     t.Cancels,
+			Parallel:    t.Parallel,
+			Context:     t.Context,
+			ContextMode: t.ContextMode,
+			Priority:    t.Priority,
+			Subworkflow: t.Subworkflow,
+			Config:      make(map[string]interface{}),
 		}
 
 		// Populate config from task-specific fields
@@ -176,18 +266,42 @@ func (p *Parser) Parse(data []byte) (*workflow.Workflow, error) {
 	}
 
 	// Convert gateways
-	for i, g := range wfYAML.Workflow.Gateways {
+	for i, g := range spec.Gateways {
 		wf.Gateways[i] = workflow.Gateway{
 			ID:      g.ID,
 			Type:    g.Type,
 			Inputs:  g.Inputs,
 			Outputs: g.Outputs,
 			WaitFor: g.WaitFor,
+			Cancels: g.Cancels,
 		}
 	}
 
-	if err := workflow.Validate(wf); err != nil {
-		return nil, fmt.Errorf("workflow validation failed: %w", err)
+	// Convert sub-workflows
+	for i, sub := range spec.Subworkflows {
+		var def *workflow.Workflow
+		var err error
+		if sub.File != "" {
+			path := sub.File
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			def, err = p.loadSubworkflow(path, loading)
+		} else {
+			def, err = p.convert(&sub.WorkflowSpecYAML, dir, loading)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("subworkflow %s: %w", sub.ID, err)
+		}
+		if def.Name == "" {
+			def.Name = sub.ID
+		}
+		wf.Subworkflows[i] = workflow.Subworkflow{
+			ID:       sub.ID,
+			Inputs:   sub.Inputs,
+			Outputs:  sub.Outputs,
+			Workflow: def,
+		}
 	}
 
 	return wf, nil
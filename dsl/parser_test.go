@@ -0,0 +1,46 @@
+package dsl
+
+import (
+	"errors"
+	"testing"
+
+	"petri-net-mvp/core/workflow"
+)
+
+// unsound lets src choose a branch while join needs both.
+const unsound = `
+workflow:
+  name: unsound
+  channels:
+    - {id: a, capacity: -1}
+    - {id: b, capacity: -1}
+    - {id: c, capacity: -1}
+  tasks:
+    - {id: src, output: a}
+    - {id: left, input: a, output: b}
+    - {id: right, input: a, output: c}
+    - {id: join, inputs: [b, c]}
+`
+
+func TestParseChecksSoundnessUnlessSkipped(t *testing.T) {
+	tests := []struct {
+		name      string
+		parser    *Parser
+		wantSound bool // whether the soundness violation is reported
+	}{
+		{"default", NewParser(), true},
+		{"opt-out", &Parser{SkipSoundness: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parser.Parse([]byte(unsound))
+			var soundness *workflow.SoundnessError
+			if got := errors.As(err, &soundness); got != tt.wantSound {
+				t.Errorf("Parse() error = %v, want soundness error %v", err, tt.wantSound)
+			}
+			if !tt.wantSound && err != nil {
+				t.Errorf("Parse() error = %v", err)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"petri-net-mvp/core/petrinet"
 	"strings"
 	"time"
@@ -92,7 +93,8 @@ func main() {
 	}()
 
 	fmt.Println("🔄 Running for 4 seconds...")
-	net.RunContinuous(ctx, 50*time.Millisecond)
+	net.AddObserver(petrinet.NewConsoleObserver(os.Stdout))
+	net.RunContinuous(ctx)
 
 	fmt.Println("\n✨ Petri net automatically handles:")
 	fmt.Println("  - Backpressure (producer slows when queue full)")
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"petri-net-mvp/core/petrinet"
 	"strings"
 	"time"
@@ -104,9 +105,11 @@ func main() {
 	ctx := context.Background()
 	startTime := time.Now()
 
-	if err := net.Run(ctx); err != nil {
+	net.AddObserver(petrinet.NewConsoleObserver(os.Stdout))
+	if _, err := net.Run(ctx, petrinet.RunOptions{}); err != nil {
 		fmt.Printf("Error: %v\n", err)
 	}
+	net.PrintState()
 
 	duration := time.Since(startTime)
 	fmt.Printf("\n⏱️  Total time: %v\n", duration)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"os"
 	"petri-net-mvp/core/petrinet"
 	"time"
 )
@@ -122,11 +123,13 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := net.Run(ctx); err != nil {
+	net.AddObserver(petrinet.NewConsoleObserver(os.Stdout))
+	if _, err := net.Run(ctx, petrinet.RunOptions{}); err != nil {
 		log.Fatalf("run failed: %v", err)
 	}
+	net.PrintState()
 
-	if len(ctxPlace.Tokens) > 0 {
-		log.Printf("🧠 final context: %+v", ctxPlace.Tokens[0].Data)
+	if tokens := net.Snapshot()[ctxPlace.ID]; len(tokens) > 0 {
+		log.Printf("🧠 final context: %+v", tokens[0].Data)
 	}
 }
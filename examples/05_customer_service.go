@@ -221,13 +221,15 @@ func main() {
 	net.AddTransition(routeRejected)
 
 	ctx := context.Background() // interactive; avoid timeouts while waiting for user input
-	if err := net.Run(ctx); err != nil {
+	net.AddObserver(petrinet.NewConsoleObserver(os.Stdout))
+	if _, err := net.Run(ctx, petrinet.RunOptions{}); err != nil {
 		log.Fatalf("run failed: %v", err)
 	}
 
-	log.Printf("🏁 finished: approved=%d rejected=%d\n", approved.TokenCount(), rejected.TokenCount())
-	printTickets("approved", approved.Tokens)
-	printTickets("rejected", rejected.Tokens)
+	final := net.Snapshot()
+	log.Printf("🏁 finished: approved=%d rejected=%d\n", len(final[approved.ID]), len(final[rejected.ID]))
+	printTickets("approved", final[approved.ID])
+	printTickets("rejected", final[rejected.ID])
 }
 
 func inferIntent(t *Ticket) string {
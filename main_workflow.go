@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"petri-net-mvp/core/petrinet"
 	"petri-net-mvp/core/workflow"
 	"petri-net-mvp/dsl"
@@ -59,7 +60,11 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := net.Run(ctx); err != nil {
+	net.AddObserver(petrinet.NewConsoleObserver(os.Stdout))
+	// load_docs has no input and keeps producing, so bound the run.
+	report, err := net.Run(ctx, petrinet.RunOptions{MaxFirings: 100})
+	report.PrintSummary(os.Stdout)
+	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
 	}